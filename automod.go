@@ -0,0 +1,531 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Violation is a rule of AutoMod broken by a chat message.
+type Violation string
+
+const (
+	ViolationBlockedWord Violation = "blockedWord"
+	ViolationLink        Violation = "link"
+	ViolationCaps        Violation = "caps"
+	ViolationEmoji       Violation = "emoji"
+	ViolationRepeat      Violation = "repeat"
+	ViolationFlood       Violation = "flood"
+)
+
+// PenaltyAction is what AutoMod does to the author of a chat message that broke a rule.
+type PenaltyAction int
+
+const (
+	// PenaltyDelete deletes the chat message.
+	PenaltyDelete PenaltyAction = iota
+	// PenaltyTimeout deletes the chat message and bans the author for the Duration of the
+	// Penalty. Without a Duration it only deletes the message, YouTube would take a ban
+	// without a duration for a permanent ban.
+	PenaltyTimeout
+	// PenaltyBan deletes the chat message and bans the author permanently.
+	PenaltyBan
+)
+
+// Penalty is a step of the escalating penalties of AutoMod, see AutoModPenalties.
+type Penalty struct {
+	Action   PenaltyAction
+	Duration time.Duration
+}
+
+// AutoModAction is a penalty given by AutoMod, see OnViolation.
+type AutoModAction struct {
+	Event     *ChatMessageEvent
+	Violation Violation
+	Penalty   Penalty
+	// Strikes is the number of violations of the author within the strike window,
+	// including this one.
+	Strikes int
+}
+
+type AutoModOption func(a *AutoMod)
+
+// BlockedWords enables the blocked word rule. Words and phrases match whole words of a
+// message case-insensitively, after undoing leetspeak such as "h3ll0" and repeated letters
+// such as "heeello". A double letter of a blocked word must be at least doubled in the
+// message, so blocking "butt" does not block "but".
+func BlockedWords(words ...string) AutoModOption {
+	return func(a *AutoMod) {
+		for _, word := range words {
+			if pattern := blockedWordPattern(normalizeWords(word, false)); pattern != "" {
+				a.blockedWords = append(a.blockedWords, pattern)
+			}
+		}
+	}
+}
+
+// BlockLinks enables the link rule for text that looks like a domain name with a scheme, a
+// www. prefix or a common top level domain. Links to the allowed domains and their
+// subdomains are allowed.
+func BlockLinks(allowedDomains ...string) AutoModOption {
+	return func(a *AutoMod) {
+		a.blockLinks = true
+		for _, domain := range allowedDomains {
+			a.allowedDomains = append(a.allowedDomains, strings.ToLower(strings.TrimPrefix(domain, ".")))
+		}
+	}
+}
+
+// CapsLimit enables the caps rule for messages with at least minLetters letters of which
+// more than ratio are upper case.
+func CapsLimit(ratio float64, minLetters int) AutoModOption {
+	return func(a *AutoMod) {
+		a.capsRatio = ratio
+		a.capsMinLetters = max(minLetters, 1)
+	}
+}
+
+// EmojiLimit enables the emoji rule for messages with more than limit emoji, counting
+// both unicode emoji and YouTube :shortcode: emoji.
+func EmojiLimit(limit int) AutoModOption {
+	return func(a *AutoMod) {
+		a.emojiLimit = max(limit, 0)
+		a.limitEmoji = true
+	}
+}
+
+// RepeatLimit enables the repeat rule for messages that repeat a character or a word more
+// than limit times in a row.
+func RepeatLimit(limit int) AutoModOption {
+	return func(a *AutoMod) {
+		a.repeatLimit = max(limit, 1)
+	}
+}
+
+// FloodLimit enables the flood rule for authors that send the same message more than
+// limit times within window.
+func FloodLimit(limit int, window time.Duration) AutoModOption {
+	return func(a *AutoMod) {
+		a.floodLimit = max(limit, 1)
+		a.floodWindow = window
+	}
+}
+
+// AutoModPenalties sets the escalating penalties, the first violation of an author gets
+// the first penalty, the second violation the second and so on, the last penalty repeats.
+// The default is delete, a 1 minute timeout, a 10 minute timeout and a ban.
+func AutoModPenalties(penalties ...Penalty) AutoModOption {
+	return func(a *AutoMod) {
+		if len(penalties) > 0 {
+			a.penalties = penalties
+		}
+	}
+}
+
+// StrikeWindow sets how long a violation counts for the escalation, the default is an
+// hour.
+func StrikeWindow(window time.Duration) AutoModOption {
+	return func(a *AutoMod) {
+		a.strikeWindow = window
+	}
+}
+
+// ExemptMembers exempts members from AutoMod, owners and moderators are always exempt.
+func ExemptMembers() AutoModOption {
+	return func(a *AutoMod) {
+		a.exempt = PermissionMember
+	}
+}
+
+// OnViolation sets a function called for every penalty given, such as for an audit log.
+func OnViolation(handler func(action AutoModAction)) AutoModOption {
+	return func(a *AutoMod) {
+		a.onViolation = handler
+	}
+}
+
+// AutoModLogger sets the logger, the default is slog.Default().
+func AutoModLogger(log *slog.Logger) AutoModOption {
+	return func(a *AutoMod) {
+		a.log = log
+	}
+}
+
+// AutoMod deletes, times out and bans the authors of chat messages that break its rules.
+// Every rule is disabled until it is enabled by its option.
+type AutoMod struct {
+	blockedWords   []string
+	blockedPattern *regexp.Regexp
+	blockLinks     bool
+	allowedDomains []string
+	capsRatio      float64
+	capsMinLetters int
+	limitEmoji     bool
+	emojiLimit     int
+	repeatLimit    int
+	floodLimit     int
+	floodWindow    time.Duration
+
+	penalties    []Penalty
+	strikeWindow time.Duration
+	exempt       Permission
+	onViolation  func(action AutoModAction)
+	log          *slog.Logger
+	now          func() time.Time
+
+	mu        sync.Mutex
+	users     map[string]*autoModUser
+	lastSweep time.Time
+}
+
+// autoModUser is the recent history of an author.
+type autoModUser struct {
+	strikes  []time.Time
+	messages []floodEntry
+}
+
+type floodEntry struct {
+	message string
+	at      time.Time
+}
+
+func NewAutoMod(options ...AutoModOption) *AutoMod {
+	a := &AutoMod{
+		penalties: []Penalty{
+			{Action: PenaltyDelete},
+			{Action: PenaltyTimeout, Duration: time.Minute},
+			{Action: PenaltyTimeout, Duration: 10 * time.Minute},
+			{Action: PenaltyBan},
+		},
+		strikeWindow: time.Hour,
+		exempt:       PermissionModerator,
+		log:          slog.Default(),
+		now:          time.Now,
+		users:        make(map[string]*autoModUser),
+	}
+	for _, option := range options {
+		option(a)
+	}
+	if len(a.blockedWords) > 0 {
+		a.blockedPattern = regexp.MustCompile(`(?:^| )(?:` + strings.Join(a.blockedWords, "|") + `)(?: |$)`)
+	}
+	return a
+}
+
+// Run moderates the events until the events channel is closed or ctx is done. The
+// penalties are written to commands, which is the BotEvent channel returned by Attach.
+func (a *AutoMod) Run(ctx context.Context, events <-chan LiveEvent, commands chan<- BotEvent) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			a.HandleEvent(ctx, event, commands)
+		}
+	}
+}
+
+// HandleEvent moderates a single event, it can be used instead of Run when the events are
+// consumed elsewhere. It reports whether a penalty was given.
+func (a *AutoMod) HandleEvent(ctx context.Context, event LiveEvent, commands chan<- BotEvent) bool {
+	msg, ok := event.(*ChatMessageEvent)
+	if !ok || PermissionOf(msg.AuthorDetails) >= a.exempt {
+		return false
+	}
+	action, ok := a.check(msg)
+	if !ok {
+		return false
+	}
+	a.log.Info("automod penalty", "violation", action.Violation, "author", msg.AuthorDetails.ChannelId, "strikes", action.Strikes)
+	if a.onViolation != nil {
+		a.onViolation(action)
+	}
+
+	penalty := []BotEvent{BotDeleteMessage{MessageID: msg.MessageID}}
+	switch action.Penalty.Action {
+	case PenaltyTimeout:
+		if action.Penalty.Duration <= 0 {
+			a.log.Warn("automod timeout without a duration, only deleting the message", "author", msg.AuthorDetails.ChannelId)
+			break
+		}
+		penalty = append(penalty, BotBanUser{ChannelID: msg.AuthorDetails.ChannelId, Duration: action.Penalty.Duration})
+	case PenaltyBan:
+		penalty = append(penalty, BotBanUser{ChannelID: msg.AuthorDetails.ChannelId})
+	}
+	for _, evt := range penalty {
+		select {
+		case commands <- evt:
+		case <-ctx.Done():
+			return true
+		}
+	}
+	return true
+}
+
+// Check returns the first rule the message breaks, without recording the message for the
+// flood rule or giving a penalty.
+func (a *AutoMod) Check(message string) (Violation, bool) {
+	switch {
+	case a.hasBlockedWord(message):
+		return ViolationBlockedWord, true
+	case a.blockLinks && a.hasBlockedLink(message):
+		return ViolationLink, true
+	case a.capsMinLetters > 0 && isCaps(message, a.capsRatio, a.capsMinLetters):
+		return ViolationCaps, true
+	case a.limitEmoji && countEmoji(message) > a.emojiLimit:
+		return ViolationEmoji, true
+	case a.repeatLimit > 0 && longestRepeat(message) > a.repeatLimit:
+		return ViolationRepeat, true
+	}
+	return "", false
+}
+
+// check records the message of the author and returns the penalty when it breaks a rule.
+func (a *AutoMod) check(msg *ChatMessageEvent) (AutoModAction, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	a.sweep(now)
+	user, ok := a.users[msg.AuthorDetails.ChannelId]
+	if !ok {
+		user = &autoModUser{}
+		a.users[msg.AuthorDetails.ChannelId] = user
+	}
+
+	violation, broken := a.Check(msg.Message)
+	if !broken && a.floodLimit > 0 && a.isFlood(user, msg.Message, now) {
+		violation, broken = ViolationFlood, true
+	}
+	if !broken {
+		return AutoModAction{}, false
+	}
+
+	user.strikes = append(strikesSince(user.strikes, now.Add(-a.strikeWindow)), now)
+	strikes := len(user.strikes)
+	return AutoModAction{
+		Event:     msg,
+		Violation: violation,
+		Penalty:   a.penalties[min(strikes, len(a.penalties))-1],
+		Strikes:   strikes,
+	}, true
+}
+
+// isFlood records the message and reports whether the author sent it more than the flood
+// limit within the flood window.
+func (a *AutoMod) isFlood(user *autoModUser, message string, now time.Time) bool {
+	normalized := normalizeWords(message, true)
+	if normalized == "" {
+		// Such as a message of only emoji.
+		normalized = strings.TrimSpace(message)
+	}
+	since := now.Add(-a.floodWindow)
+	user.messages = messagesSince(user.messages, since)
+	user.messages = append(user.messages, floodEntry{message: normalized, at: now})
+	var same int
+	for _, entry := range user.messages {
+		if entry.message == normalized {
+			same++
+		}
+	}
+	return same > a.floodLimit
+}
+
+// sweep forgets the authors without recent strikes or messages. a.mu must be held.
+func (a *AutoMod) sweep(now time.Time) {
+	window := max(a.strikeWindow, a.floodWindow)
+	if now.Sub(a.lastSweep) < window {
+		return
+	}
+	a.lastSweep = now
+	for channelID, user := range a.users {
+		user.strikes = strikesSince(user.strikes, now.Add(-a.strikeWindow))
+		user.messages = messagesSince(user.messages, now.Add(-a.floodWindow))
+		if len(user.strikes) == 0 && len(user.messages) == 0 {
+			delete(a.users, channelID)
+		}
+	}
+}
+
+func strikesSince(times []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(since) {
+		i++
+	}
+	return times[i:]
+}
+
+func messagesSince(entries []floodEntry, since time.Time) []floodEntry {
+	i := 0
+	for i < len(entries) && entries[i].at.Before(since) {
+		i++
+	}
+	return entries[i:]
+}
+
+func (a *AutoMod) hasBlockedWord(message string) bool {
+	if a.blockedPattern == nil {
+		return false
+	}
+	return a.blockedPattern.MatchString(normalizeWords(message, false))
+}
+
+// blockedWordPattern matches the normalized words with every run of a letter repeated at
+// least as many times, such as "he+l{2,}o+" for "hello".
+func blockedWordPattern(words string) string {
+	var pattern strings.Builder
+	runes := []rune(words)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		pattern.WriteString(regexp.QuoteMeta(string(runes[i])))
+		switch {
+		case runes[i] == ' ':
+		case j-i == 1:
+			pattern.WriteString("+")
+		default:
+			fmt.Fprintf(&pattern, "{%d,}", j-i)
+		}
+		i = j
+	}
+	return pattern.String()
+}
+
+// leetspeak maps the characters commonly used in place of letters.
+var leetspeak = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i', '+': 't',
+}
+
+// normalizeWords lower cases the text, undoes leetspeak and drops the other characters
+// that are not letters, repeated letters are collapsed when collapse is set. The words are
+// joined by single spaces.
+func normalizeWords(text string, collapse bool) string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		var (
+			word strings.Builder
+			last rune
+		)
+		// Punctuation around a word is not leetspeak, such as the ! of "hello!".
+		field = strings.TrimFunc(field, func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '@' && c != '$'
+		})
+		for _, c := range strings.ToLower(field) {
+			if r, ok := leetspeak[c]; ok {
+				c = r
+			}
+			if !unicode.IsLetter(c) || (collapse && c == last) {
+				continue
+			}
+			word.WriteRune(c)
+			last = c
+		}
+		if word.Len() > 0 {
+			words = append(words, word.String())
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// linkPattern matches a host name, with its scheme or www. prefix if any, and its top
+// level domain.
+var linkPattern = regexp.MustCompile(`(?i)\b(https?://|www\.)?((?:[a-z0-9-]+\.)+([a-z]{2,}))\b`)
+
+// linkTLDs are the top level domains that make text without a scheme or www. prefix a
+// link, so text like "Mr.Bean" or "ok.thanks" is not one.
+var linkTLDs = map[string]bool{
+	"app": true, "biz": true, "br": true, "cc": true, "click": true, "cn": true, "co": true,
+	"com": true, "de": true, "dev": true, "fr": true, "gg": true, "in": true, "info": true,
+	"io": true, "jp": true, "link": true, "live": true, "ly": true, "me": true, "net": true,
+	"online": true, "org": true, "ru": true, "shop": true, "site": true, "store": true,
+	"to": true, "top": true, "tv": true, "uk": true, "us": true, "xyz": true,
+}
+
+func (a *AutoMod) hasBlockedLink(message string) bool {
+	for _, match := range linkPattern.FindAllStringSubmatch(message, -1) {
+		if match[1] == "" && !linkTLDs[strings.ToLower(match[3])] {
+			continue
+		}
+		if !a.allowedDomain(strings.ToLower(match[2])) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AutoMod) allowedDomain(host string) bool {
+	for _, domain := range a.allowedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCaps(message string, ratio float64, minLetters int) bool {
+	var letters, upper int
+	for _, c := range message {
+		if unicode.IsLetter(c) {
+			letters++
+			if unicode.IsUpper(c) {
+				upper++
+			}
+		}
+	}
+	return letters >= minLetters && float64(upper)/float64(letters) > ratio
+}
+
+var shortcodeEmoji = regexp.MustCompile(`:[a-zA-Z0-9_-]+:`)
+
+func countEmoji(message string) int {
+	n := len(shortcodeEmoji.FindAllStringIndex(message, -1))
+	for _, c := range shortcodeEmoji.ReplaceAllString(message, "") {
+		if isEmoji(c) {
+			n++
+		}
+	}
+	return n
+}
+
+func isEmoji(c rune) bool {
+	return (c >= 0x1F300 && c <= 0x1FAFF) || (c >= 0x2600 && c <= 0x27BF) || (c >= 0x1F1E6 && c <= 0x1F1FF)
+}
+
+// longestRepeat returns the longest run of the same character, ignoring whitespace, or of
+// the same word.
+func longestRepeat(message string) int {
+	longest, run := 0, 0
+	var last rune
+	for _, c := range message {
+		if unicode.IsSpace(c) {
+			continue
+		}
+		if c == last {
+			run++
+		} else {
+			run, last = 1, c
+		}
+		longest = max(longest, run)
+	}
+	run = 0
+	var lastWord string
+	for _, word := range strings.Fields(strings.ToLower(message)) {
+		if word == lastWord {
+			run++
+		} else {
+			run, lastWord = 1, word
+		}
+		longest = max(longest, run)
+	}
+	return longest
+}
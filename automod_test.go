@@ -0,0 +1,168 @@
+package youtubelive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoMod_Check(t *testing.T) {
+	a := NewAutoMod(
+		BlockedWords("darn", "buy followers", "butt", "ass"),
+		BlockLinks("youtube.com", "youtu.be"),
+		CapsLimit(0.7, 8),
+		EmojiLimit(3),
+		RepeatLimit(5),
+	)
+	tests := []struct {
+		message string
+		want    Violation
+	}{
+		{"hello there!", ""},
+		{"well darn it", ViolationBlockedWord},
+		{"well D4RN!", ViolationBlockedWord},
+		{"d@@@rrrn", ViolationBlockedWord},
+		{"darning socks", ""},
+		{"but why", ""},
+		{"as soon as", ""},
+		{"buuuutttt", ViolationBlockedWord},
+		{"a$$", ViolationBlockedWord},
+		{"cheap BUY   f0llowers now", ViolationBlockedWord},
+		{"see https://spam.example.com/x", ViolationLink},
+		{"visit spam.ru", ViolationLink},
+		{"watch https://www.youtube.com/watch?v=1 and youtu.be/2", ""},
+		{"wait...what", ""},
+		{"Mr.Bean is funny", ""},
+		{"ok.thanks", ""},
+		{"go to www.spam.example", ViolationLink},
+		{"http://spam.example", ViolationLink},
+		{"THIS IS SO GOOD", ViolationCaps},
+		{"OMG", ""},
+		{"great 🎉🎉🎉", ""},
+		{"great 🎉🎉 :yt: :hand-pink-waving:", ViolationEmoji},
+		{"nooooooo", ViolationRepeat},
+		{"lol lol lol lol lol lol", ViolationRepeat},
+		{"lol lol lol", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			got, ok := a.Check(tt.message)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want != "", ok)
+		})
+	}
+}
+
+func TestAutoMod_Escalation(t *testing.T) {
+	now := time.Unix(0, 0)
+	var actions []AutoModAction
+	a := NewAutoMod(
+		BlockedWords("spam"),
+		StrikeWindow(time.Hour),
+		OnViolation(func(action AutoModAction) { actions = append(actions, action) }),
+	)
+	a.now = func() time.Time { return now }
+	viewer := AuthorDetails{ChannelId: "UCviewer"}
+	commands := make(chan BotEvent, 10)
+	ctx := context.Background()
+
+	handle := func(message string) []BotEvent {
+		a.HandleEvent(ctx, chatFrom(viewer, message), commands)
+		var got []BotEvent
+		for len(commands) > 0 {
+			got = append(got, <-commands)
+		}
+		return got
+	}
+
+	assert.Empty(t, handle("hello"))
+	assert.Equal(t, []BotEvent{BotDeleteMessage{MessageID: "id-spam 1"}}, handle("spam 1"))
+	assert.Equal(t, []BotEvent{
+		BotDeleteMessage{MessageID: "id-spam 2"},
+		BotBanUser{ChannelID: "UCviewer", Duration: time.Minute},
+	}, handle("spam 2"))
+	assert.Equal(t, []BotEvent{
+		BotDeleteMessage{MessageID: "id-spam 3"},
+		BotBanUser{ChannelID: "UCviewer", Duration: 10 * time.Minute},
+	}, handle("spam 3"))
+	assert.Equal(t, []BotEvent{
+		BotDeleteMessage{MessageID: "id-spam 4"},
+		BotBanUser{ChannelID: "UCviewer"},
+	}, handle("spam 4"))
+	if assert.Len(t, actions, 4) {
+		assert.Equal(t, ViolationBlockedWord, actions[3].Violation)
+		assert.Equal(t, 4, actions[3].Strikes)
+	}
+
+	// The strikes expire.
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, []BotEvent{BotDeleteMessage{MessageID: "id-spam 5"}}, handle("spam 5"))
+}
+
+func TestAutoMod_Penalties(t *testing.T) {
+	viewer := AuthorDetails{ChannelId: "UCviewer"}
+	tests := []struct {
+		name    string
+		penalty Penalty
+		want    []BotEvent
+	}{
+		{"delete", Penalty{Action: PenaltyDelete}, []BotEvent{BotDeleteMessage{MessageID: "id-spam"}}},
+		{"timeout", Penalty{Action: PenaltyTimeout, Duration: time.Minute}, []BotEvent{
+			BotDeleteMessage{MessageID: "id-spam"},
+			BotBanUser{ChannelID: "UCviewer", Duration: time.Minute},
+		}},
+		{"timeout without duration only deletes", Penalty{Action: PenaltyTimeout}, []BotEvent{BotDeleteMessage{MessageID: "id-spam"}}},
+		{"ban", Penalty{Action: PenaltyBan}, []BotEvent{
+			BotDeleteMessage{MessageID: "id-spam"},
+			BotBanUser{ChannelID: "UCviewer"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAutoMod(BlockedWords("spam"), AutoModPenalties(tt.penalty))
+			commands := make(chan BotEvent, 10)
+			assert.True(t, a.HandleEvent(context.Background(), chatFrom(viewer, "spam"), commands))
+			close(commands)
+			var got []BotEvent
+			for evt := range commands {
+				got = append(got, evt)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAutoMod_Flood(t *testing.T) {
+	now := time.Unix(0, 0)
+	a := NewAutoMod(FloodLimit(2, 10*time.Second))
+	a.now = func() time.Time { return now }
+	commands := make(chan BotEvent, 10)
+	viewer := AuthorDetails{ChannelId: "UCviewer"}
+	other := AuthorDetails{ChannelId: "UCother"}
+	ctx := context.Background()
+
+	assert.False(t, a.HandleEvent(ctx, chatFrom(viewer, "first!"), commands))
+	assert.False(t, a.HandleEvent(ctx, chatFrom(other, "first!"), commands))
+	assert.False(t, a.HandleEvent(ctx, chatFrom(viewer, "FIRST"), commands))
+	assert.True(t, a.HandleEvent(ctx, chatFrom(viewer, "first"), commands), "third time within the window")
+
+	now = now.Add(11 * time.Second)
+	assert.False(t, a.HandleEvent(ctx, chatFrom(viewer, "first"), commands))
+}
+
+func TestAutoMod_Exempt(t *testing.T) {
+	commands := make(chan BotEvent, 10)
+	ctx := context.Background()
+	member := AuthorDetails{ChannelId: "UCmember", IsChatSponsor: true}
+	moderator := AuthorDetails{ChannelId: "UCmod", IsChatModerator: true}
+
+	a := NewAutoMod(BlockedWords("spam"))
+	assert.True(t, a.HandleEvent(ctx, chatFrom(member, "spam"), commands))
+	assert.False(t, a.HandleEvent(ctx, chatFrom(moderator, "spam"), commands))
+	assert.False(t, a.HandleEvent(ctx, chatFrom(AuthorDetails{IsChatOwner: true}, "spam"), commands))
+
+	a = NewAutoMod(BlockedWords("spam"), ExemptMembers())
+	assert.False(t, a.HandleEvent(ctx, chatFrom(member, "spam"), commands))
+}
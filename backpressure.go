@@ -0,0 +1,292 @@
+package youtubelive
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+const defaultEventBuffer = 100
+
+// OverflowPolicy decides what happens to the events of an attached chat when the consumer
+// falls behind and the buffer set with EventBuffer is full, see EventOverflow.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stops receiving the chat until the consumer catches up, this is the
+	// default. A consumer that stays behind for too long misses messages that are past the
+	// retention of the chat.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest buffered event to make room for a new one,
+	// the new event is discarded when the buffer only holds events that are never
+	// dropped. ChatEndedEvent and ErrorEvent are never dropped, they are buffered beyond
+	// the size.
+	OverflowDropOldest
+	// OverflowDropNewest discards the new events until there is room. Like with
+	// OverflowDropOldest, ChatEndedEvent and ErrorEvent are never dropped.
+	OverflowDropNewest
+	// OverflowSpill writes the events that do not fit in the buffer to a temporary file
+	// and delivers them in order once the consumer catches up, see SpillDir.
+	OverflowSpill
+)
+
+var overflowPolicyNames = []string{"block", "dropOldest", "dropNewest", "spill"}
+
+func (p OverflowPolicy) String() string {
+	if p < 0 || int(p) >= len(overflowPolicyNames) {
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+	return overflowPolicyNames[p]
+}
+
+func (p OverflowPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *OverflowPolicy) UnmarshalText(text []byte) error {
+	for i, name := range overflowPolicyNames {
+		if name == string(text) {
+			*p = OverflowPolicy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown overflow policy %q", text)
+}
+
+// eventQueue buffers the events of an attached chat between the goroutines receiving the
+// chat and the consumer, it applies the OverflowPolicy when the consumer falls behind. It
+// is only used by the relay goroutine, except for queued.
+type eventQueue struct {
+	yt     *YouTubeLive
+	size   int
+	policy OverflowPolicy
+
+	events []LiveEvent
+	spill  spillFile
+	// queued is the number of buffered and spilled events, for the backlog metric.
+	queued atomic.Int64
+
+	dropped  int
+	total    int
+	lastDrop time.Time
+	notice   *EventsDroppedEvent
+}
+
+func newEventQueue(yt *YouTubeLive) *eventQueue {
+	return &eventQueue{
+		yt:     yt,
+		size:   max(yt.eventBuffer, 1),
+		policy: yt.overflow,
+		spill:  spillFile{dir: yt.spillDir},
+	}
+}
+
+// newEventChannel returns the channel the events of a chat are written to and the channel
+// the consumer receives them from, and the backlog of the latter. With OverflowBlock they
+// are the same buffered channel, otherwise an eventQueue relays the events until ctx is
+// done. out is closed after events is.
+func (yt *YouTubeLive) newEventChannel(ctx context.Context) (events, out chan LiveEvent, backlog func() int) {
+	if yt.overflow == OverflowBlock {
+		out = make(chan LiveEvent, yt.eventBuffer)
+		return out, out, func() int { return len(out) }
+	}
+	events, out = make(chan LiveEvent), make(chan LiveEvent)
+	queue := newEventQueue(yt)
+	go queue.relay(ctx, events, out)
+	return events, out, queue.backlog
+}
+
+// relay moves the events from in to out until in is closed and every event was delivered,
+// or until ctx is done. out is closed when relay returns.
+func (q *eventQueue) relay(ctx context.Context, in <-chan LiveEvent, out chan<- LiveEvent) {
+	defer close(out)
+	defer q.spill.remove()
+	for in != nil || len(q.events) > 0 || q.dropped > 0 {
+		var send chan<- LiveEvent
+		next := q.peek()
+		if next != nil {
+			send = out
+		}
+		select {
+		case event, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			q.push(event)
+		case send <- next:
+			q.pop()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *eventQueue) backlog() int {
+	return int(q.queued.Load())
+}
+
+func (q *eventQueue) push(event LiveEvent) {
+	if len(q.events) < q.size && q.spill.n == 0 {
+		q.events = append(q.events, event)
+		q.queued.Add(1)
+		return
+	}
+	if q.policy != OverflowSpill && undroppable(event) {
+		q.events = append(q.events, event)
+		q.queued.Add(1)
+		return
+	}
+	switch q.policy {
+	case OverflowDropOldest:
+		i := slices.IndexFunc(q.events, func(e LiveEvent) bool { return !undroppable(e) })
+		if i >= 0 {
+			// The oldest event that may be dropped makes room.
+			q.events = append(slices.Delete(q.events, i, i+1), event)
+		}
+		q.drop()
+	case OverflowSpill:
+		if err := q.spill.write(event); err != nil {
+			if undroppable(event) {
+				// Out of order with the spilled events, but not lost.
+				q.yt.log.Warn("failed to spill event to disk, buffering it", "error", err)
+				q.events = append(q.events, event)
+				q.queued.Add(1)
+				return
+			}
+			q.yt.log.Warn("failed to spill event to disk, dropping it", "error", err)
+			q.drop()
+			return
+		}
+		q.queued.Add(1)
+	default:
+		q.drop()
+	}
+}
+
+// undroppable reports whether the event must reach the consumer even when it is behind,
+// the end of the chat and errors are how the consumer learns the chat stopped.
+func undroppable(event LiveEvent) bool {
+	switch event.(type) {
+	case *ChatEndedEvent, *ErrorEvent:
+		return true
+	}
+	return false
+}
+
+// peek returns the next event to deliver, an EventsDroppedEvent goes first when events
+// were dropped since the last one.
+func (q *eventQueue) peek() LiveEvent {
+	q.notice = nil
+	if q.dropped > 0 {
+		q.notice = &EventsDroppedEvent{
+			Dropped:   q.dropped,
+			Total:     q.total,
+			Policy:    q.policy,
+			Timestamp: q.lastDrop,
+		}
+		return q.notice
+	}
+	if len(q.events) == 0 {
+		return nil
+	}
+	return q.events[0]
+}
+
+// pop removes the event returned by peek and refills the buffer from the spill file.
+func (q *eventQueue) pop() {
+	if q.notice != nil {
+		q.notice = nil
+		q.dropped = 0
+		return
+	}
+	q.events[0] = nil
+	q.events = q.events[1:]
+	q.queued.Add(-1)
+	if q.spill.n == 0 {
+		return
+	}
+	event, err := q.spill.read()
+	if err != nil {
+		q.yt.log.Warn("failed to read spilled event, dropping it", "error", err)
+		q.queued.Add(-1)
+		q.drop()
+		return
+	}
+	q.events = append(q.events, event)
+}
+
+func (q *eventQueue) drop() {
+	q.dropped++
+	q.total++
+	q.lastDrop = time.Now().UTC()
+	q.yt.metrics.observeDropped(q.policy)
+}
+
+// spillFile is a first in first out file of events encoded with MarshalEvent, one per
+// line. The file is created on the first write and removed once every event was read.
+type spillFile struct {
+	dir    string
+	file   *os.File
+	reader *os.File
+	lines  *bufio.Reader
+	n      int
+}
+
+func (s *spillFile) write(event LiveEvent) error {
+	data, err := MarshalEvent(event)
+	if err != nil {
+		return err
+	}
+	if s.file == nil {
+		if err := s.create(); err != nil {
+			return err
+		}
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.n++
+	return nil
+}
+
+func (s *spillFile) create() error {
+	file, err := os.CreateTemp(s.dir, "youtubelive-spill-*.jsonl")
+	if err != nil {
+		return err
+	}
+	reader, err := os.Open(file.Name())
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return err
+	}
+	s.file, s.reader, s.lines = file, reader, bufio.NewReader(reader)
+	return nil
+}
+
+func (s *spillFile) read() (LiveEvent, error) {
+	line, err := s.lines.ReadBytes('\n')
+	s.n--
+	if s.n == 0 {
+		s.remove()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalEvent(line)
+}
+
+func (s *spillFile) remove() {
+	if s.file == nil {
+		return
+	}
+	_ = s.reader.Close()
+	_ = s.file.Close()
+	_ = os.Remove(s.file.Name())
+	s.file, s.reader, s.lines, s.n = nil, nil, nil, 0
+}
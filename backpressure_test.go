@@ -0,0 +1,134 @@
+package youtubelive
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// relayAll sends the chat messages to a queue while nothing reads its output and returns
+// everything it delivers afterwards.
+func relayAll(t *testing.T, yt *YouTubeLive, messages ...string) []LiveEvent {
+	t.Helper()
+	in, out := make(chan LiveEvent), make(chan LiveEvent)
+	go newEventQueue(yt).relay(context.Background(), in, out)
+	for _, message := range messages {
+		in <- &ChatMessageEvent{Message: message}
+	}
+	close(in)
+	return collect(out)
+}
+
+func messages(events []LiveEvent) []string {
+	var got []string
+	for _, event := range events {
+		if e, ok := event.(*ChatMessageEvent); ok {
+			got = append(got, e.Message)
+		}
+	}
+	return got
+}
+
+func TestEventQueue_Policies(t *testing.T) {
+	tests := []struct {
+		policy  OverflowPolicy
+		want    []string
+		dropped int
+	}{
+		{OverflowDropOldest, []string{"4", "5"}, 3},
+		{OverflowDropNewest, []string{"1", "2"}, 3},
+		{OverflowSpill, []string{"1", "2", "3", "4", "5"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			yt := newTestYouTubeLive(t, newFakeYouTube(), EventBuffer(2), EventOverflow(tt.policy), SpillDir(dir))
+			got := relayAll(t, yt, "1", "2", "3", "4", "5")
+			assert.Equal(t, tt.want, messages(got))
+
+			if tt.dropped == 0 {
+				assert.Len(t, got, len(tt.want))
+			} else if assert.IsType(t, &EventsDroppedEvent{}, got[0]) {
+				dropped := got[0].(*EventsDroppedEvent)
+				assert.Equal(t, tt.dropped, dropped.Dropped)
+				assert.Equal(t, tt.dropped, dropped.Total)
+				assert.Equal(t, tt.policy, dropped.Policy)
+			}
+			files, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			assert.Empty(t, files, "spill files are removed")
+		})
+	}
+}
+
+func TestEventQueue_KeepsTerminalEvents(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowDropOldest, OverflowDropNewest} {
+		t.Run(policy.String(), func(t *testing.T) {
+			yt := newTestYouTubeLive(t, newFakeYouTube(), EventBuffer(2), EventOverflow(policy))
+			in, out := make(chan LiveEvent), make(chan LiveEvent)
+			go newEventQueue(yt).relay(context.Background(), in, out)
+			for _, message := range []string{"1", "2", "3"} {
+				in <- &ChatMessageEvent{Message: message}
+			}
+			in <- &ErrorEvent{Error: assert.AnError}
+			in <- &ChatEndedEvent{}
+			close(in)
+
+			got := collect(out)
+			if assert.Len(t, got, 5) {
+				assert.IsType(t, &EventsDroppedEvent{}, got[0])
+				assert.IsType(t, &ErrorEvent{}, got[3])
+				assert.IsType(t, &ChatEndedEvent{}, got[4])
+			}
+		})
+	}
+}
+
+func TestEventQueue_DropOldestKeepsBufferedError(t *testing.T) {
+	yt := newTestYouTubeLive(t, newFakeYouTube(), EventBuffer(1), EventOverflow(OverflowDropOldest))
+	in, out := make(chan LiveEvent), make(chan LiveEvent)
+	go newEventQueue(yt).relay(context.Background(), in, out)
+	in <- &ErrorEvent{Error: assert.AnError}
+	in <- &ChatMessageEvent{Message: "1"}
+	in <- &ChatMessageEvent{Message: "2"}
+	close(in)
+
+	got := collect(out)
+	// The buffer only holds the error, so the new messages are dropped instead.
+	if assert.Len(t, got, 2) {
+		assert.IsType(t, &EventsDroppedEvent{}, got[0])
+		assert.Equal(t, 2, got[0].(*EventsDroppedEvent).Dropped)
+		assert.IsType(t, &ErrorEvent{}, got[1])
+	}
+}
+
+func TestYouTubeLive_AttachOverflow(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake, EventBuffer(1), EventOverflow(OverflowDropNewest))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake.queue(textMessage("1", "viewer", "first"), textMessage("2", "viewer", "second"), textMessage("3", "viewer", "third"))
+	events, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// The chat keeps being polled while nothing reads the events.
+	assert.Eventually(t, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return fake.calls["/youtube/v3/liveChat/messages"] >= 3
+	}, 2*time.Second, 5*time.Millisecond)
+
+	if dropped, ok := (<-events).(*EventsDroppedEvent); assert.True(t, ok) {
+		assert.Equal(t, 2, dropped.Dropped)
+	}
+	assert.Equal(t, "first", nextChatMessage(t, events).Message)
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,400 @@
+package youtubelive
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BanSource tells how a BanLedger learned about a ban.
+type BanSource string
+
+const (
+	// BanObserved is a ban seen as a UserBannedEvent in an attached chat.
+	BanObserved BanSource = "observed"
+	// BanIssued is a ban issued through the library with BotBanUser or BanUser, its
+	// moderator is the logged-in channel. When the chat is attached the ban is observed as
+	// well, it is only recorded once, see BanLedger.
+	BanIssued BanSource = "issued"
+)
+
+// banDedupWindow is how far apart an issued and an observed ban of the same user in the
+// same chat are taken to be the same ban.
+const banDedupWindow = time.Minute
+
+// BanRecord is a ban recorded by a BanLedger.
+type BanRecord struct {
+	LiveChatID            string        `json:"liveChatId"`
+	Source                BanSource     `json:"source"`
+	BannedUserID          string        `json:"bannedUserId"`
+	BannedUserDisplayName string        `json:"bannedUserDisplayName,omitempty"`
+	ModeratorID           string        `json:"moderatorId,omitempty"`
+	ModeratorDisplayName  string        `json:"moderatorDisplayName,omitempty"`
+	BanType               string        `json:"banType"` // "permanent" or "temporary"
+	Duration              time.Duration `json:"duration,omitempty"`
+	Timestamp             time.Time     `json:"timestamp"`
+	// ExpiresAt is when a temporary ban ends, it is zero for permanent bans.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Expired reports whether the ban is a temporary ban that has ended at t.
+func (b BanRecord) Expired(t time.Time) bool {
+	return !b.ExpiresAt.IsZero() && !t.Before(b.ExpiresAt)
+}
+
+// BanStore persists the records of a BanLedger, it must be safe for concurrent use.
+type BanStore interface {
+	AddBan(record BanRecord) error
+	// LastBan returns the most recently added record of the banned user in the live chat,
+	// ok is false when there is none.
+	LastBan(liveChatID, bannedUserID string) (record BanRecord, ok bool, err error)
+	// UpdateBan replaces the record returned by LastBan for the live chat and banned user
+	// of record.
+	UpdateBan(record BanRecord) error
+	// Bans returns every record in the order they were added.
+	Bans() ([]BanRecord, error)
+}
+
+// banKey identifies the records of a user in a live chat.
+type banKey struct {
+	liveChatID   string
+	bannedUserID string
+}
+
+func (b BanRecord) key() banKey {
+	return banKey{liveChatID: b.LiveChatID, bannedUserID: b.BannedUserID}
+}
+
+// BanLedger records the bans of live chats to a BanStore, both the bans seen in attached
+// chats and the bans issued through the library, see WithBanLedger. The YouTube API has no
+// way to list the bans of a chat, so the ledger only knows about the bans that happened
+// while it was recording.
+//
+// A ban issued through the library and observed in the chat is recorded once: bans of the
+// same user in the same chat within a minute of each other are taken to be the same ban.
+// The record of whichever comes first is kept and what it lacks, like the display names
+// of an observed ban, is filled in from the other.
+type BanLedger struct {
+	// mu makes finding a duplicate and adding the record atomic.
+	mu    sync.Mutex
+	store BanStore
+	now   func() time.Time
+}
+
+// NewBanLedger creates a ledger that records to store.
+func NewBanLedger(store BanStore) *BanLedger {
+	return &BanLedger{store: store, now: time.Now}
+}
+
+// Record adds the ban of a UserBannedEvent seen in the live chat.
+func (l *BanLedger) Record(liveChatID string, event *UserBannedEvent) error {
+	record := BanRecord{
+		LiveChatID:            liveChatID,
+		Source:                BanObserved,
+		BannedUserID:          event.BannedUserID,
+		BannedUserDisplayName: event.BannedUserDisplayName,
+		ModeratorID:           event.ModeratorID,
+		ModeratorDisplayName:  event.ModeratorDisplayName,
+		BanType:               event.BanType,
+		Duration:              event.Duration,
+		Timestamp:             event.Timestamp,
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = l.now().UTC()
+	}
+	if event.BanType == "temporary" {
+		record.ExpiresAt = record.Timestamp.Add(event.Duration)
+	}
+	return l.add(record)
+}
+
+// recordIssued adds a ban issued through the library by the moderator channel ID, duration
+// is 0 for permanent bans.
+func (l *BanLedger) recordIssued(liveChatID, channelID, moderatorID string, duration time.Duration) error {
+	record := BanRecord{
+		LiveChatID:   liveChatID,
+		Source:       BanIssued,
+		BannedUserID: channelID,
+		ModeratorID:  moderatorID,
+		BanType:      "permanent",
+		Timestamp:    l.now().UTC(),
+	}
+	if duration > 0 {
+		record.BanType = "temporary"
+		record.Duration = duration
+		record.ExpiresAt = record.Timestamp.Add(duration)
+	}
+	return l.add(record)
+}
+
+// add stores the record, or merges it into the record of the ban it is the issued or
+// observed counterpart of.
+func (l *BanLedger) add(record BanRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	last, ok, err := l.store.LastBan(record.LiveChatID, record.BannedUserID)
+	if err != nil {
+		return err
+	}
+	if ok && last.Source != record.Source && last.Timestamp.Sub(record.Timestamp).Abs() <= banDedupWindow {
+		return l.store.UpdateBan(mergeBan(last, record))
+	}
+	return l.store.AddBan(record)
+}
+
+// mergeBan fills the fields record lacks from other, the record of the same ban from the
+// other source.
+func mergeBan(record, other BanRecord) BanRecord {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&record.BannedUserDisplayName, other.BannedUserDisplayName)
+	fill(&record.ModeratorID, other.ModeratorID)
+	fill(&record.ModeratorDisplayName, other.ModeratorDisplayName)
+	return record
+}
+
+// Bans returns every recorded ban, the oldest first.
+func (l *BanLedger) Bans() ([]BanRecord, error) {
+	return l.store.Bans()
+}
+
+// ByUser returns the recorded bans of the banned channel ID.
+func (l *BanLedger) ByUser(channelID string) ([]BanRecord, error) {
+	return l.filter(func(b BanRecord) bool { return b.BannedUserID == channelID })
+}
+
+// ByModerator returns the recorded bans issued by the moderator channel ID.
+func (l *BanLedger) ByModerator(channelID string) ([]BanRecord, error) {
+	return l.filter(func(b BanRecord) bool { return b.ModeratorID == channelID })
+}
+
+// Active returns the recorded bans that have not expired at t, permanent bans never
+// expire.
+func (l *BanLedger) Active(t time.Time) ([]BanRecord, error) {
+	return l.filter(func(b BanRecord) bool { return !b.Expired(t) })
+}
+
+func (l *BanLedger) filter(keep func(BanRecord) bool) ([]BanRecord, error) {
+	bans, err := l.store.Bans()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(bans, func(b BanRecord) bool { return !keep(b) }), nil
+}
+
+// MemoryBanStore is a BanStore that keeps the records in memory.
+type MemoryBanStore struct {
+	mu   sync.Mutex
+	bans []BanRecord
+	// last is the index in bans of the last record of every user and chat.
+	last map[banKey]int
+}
+
+func NewMemoryBanStore() *MemoryBanStore {
+	return &MemoryBanStore{last: make(map[banKey]int)}
+}
+
+func (s *MemoryBanStore) AddBan(record BanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addLocked(record)
+	return nil
+}
+
+func (s *MemoryBanStore) LastBan(liveChatID, bannedUserID string) (BanRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.last[banKey{liveChatID: liveChatID, bannedUserID: bannedUserID}]
+	if !ok {
+		return BanRecord{}, false, nil
+	}
+	return s.bans[i], true, nil
+}
+
+func (s *MemoryBanStore) UpdateBan(record BanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateLocked(record)
+	return nil
+}
+
+func (s *MemoryBanStore) addLocked(record BanRecord) {
+	s.last[record.key()] = len(s.bans)
+	s.bans = append(s.bans, record)
+}
+
+// updateLocked replaces the last record of the user and chat, or adds record when there
+// is none.
+func (s *MemoryBanStore) updateLocked(record BanRecord) {
+	i, ok := s.last[record.key()]
+	if !ok {
+		s.addLocked(record)
+		return
+	}
+	s.bans[i] = record
+}
+
+func (s *MemoryBanStore) Bans() ([]BanRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.bans), nil
+}
+
+// FileBanStore is a BanStore that appends the records to a file as JSON, one per line, so
+// the ledger survives restarts. The existing records are read when the file is opened.
+// An updated record is appended as well, a line with the user, chat and timestamp of the
+// record before it replaces that record when the file is read.
+type FileBanStore struct {
+	MemoryBanStore
+	file *os.File
+}
+
+// OpenFileBanStore opens or creates the file of a FileBanStore.
+func OpenFileBanStore(path string) (*FileBanStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileBanStore{MemoryBanStore: MemoryBanStore{last: make(map[banKey]int)}, file: file}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record BanRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		if last, ok := s.last[record.key()]; ok && s.bans[last].Timestamp.Equal(record.Timestamp) {
+			s.bans[last] = record
+			continue
+		}
+		s.addLocked(record)
+	}
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileBanStore) AddBan(record BanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.appendLocked(record); err != nil {
+		return err
+	}
+	s.addLocked(record)
+	return nil
+}
+
+// UpdateBan appends the record, the timestamp of the record must not change so it
+// replaces the previous one when the file is read again.
+func (s *FileBanStore) UpdateBan(record BanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.appendLocked(record); err != nil {
+		return err
+	}
+	s.updateLocked(record)
+	return nil
+}
+
+func (s *FileBanStore) appendLocked(record BanRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+func (s *FileBanStore) Close() error {
+	return s.file.Close()
+}
+
+var banCSVHeader = []string{
+	"timestamp", "live_chat_id", "source", "banned_user_id", "banned_user_display_name",
+	"moderator_id", "moderator_display_name", "ban_type", "duration_seconds", "expires_at",
+}
+
+// WriteBansCSV writes the records as CSV with a header row, for an audit of the bans.
+func WriteBansCSV(w io.Writer, records []BanRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(banCSVHeader); err != nil {
+		return err
+	}
+	for _, b := range records {
+		var expiresAt string
+		if !b.ExpiresAt.IsZero() {
+			expiresAt = b.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		err := cw.Write([]string{
+			b.Timestamp.UTC().Format(time.RFC3339),
+			b.LiveChatID,
+			string(b.Source),
+			b.BannedUserID,
+			b.BannedUserDisplayName,
+			b.ModeratorID,
+			b.ModeratorDisplayName,
+			b.BanType,
+			strconv.FormatInt(int64(b.Duration.Seconds()), 10),
+			expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteBansJSON writes the records as an indented JSON array.
+func WriteBansJSON(w io.Writer, records []BanRecord) error {
+	if records == nil {
+		records = []BanRecord{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// moderatorChannelID returns the channel ID of the logged-in user, the moderator of the
+// bans issued through the library. It is looked up once per login, it is blank when the
+// lookup fails.
+func (yt *YouTubeLive) moderatorChannelID(ctx context.Context) string {
+	client := yt.client()
+	yt.moderatorMu.Lock()
+	defer yt.moderatorMu.Unlock()
+	if yt.moderator.client == client {
+		return yt.moderator.channelID
+	}
+	_, channelID, err := yt.LoggedInChannelContext(ctx)
+	if err != nil {
+		yt.log.Warn("failed to look up the moderator of an issued ban", "error", err)
+		return ""
+	}
+	yt.moderator.client, yt.moderator.channelID = client, channelID
+	return channelID
+}
+
+// recordBan records a ban of the chat in the ledger of the WithBanLedger option, if any.
+func (yt *YouTubeLive) recordBan(record func(l *BanLedger) error) {
+	if yt.banLedger == nil {
+		return
+	}
+	if err := record(yt.banLedger); err != nil {
+		yt.log.Warn("failed to record ban", "error", err)
+	}
+}
@@ -0,0 +1,204 @@
+package youtubelive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestBanLedger_Queries(t *testing.T) {
+	ledger := NewBanLedger(NewMemoryBanStore())
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ledger.now = func() time.Time { return start }
+
+	assert.NoError(t, ledger.Record("chat", &UserBannedEvent{
+		BannedUserID: "spammer", BanType: "temporary", Duration: 5 * time.Minute,
+		ModeratorID: "mod-a", ModeratorDisplayName: "Mod A", Timestamp: start,
+	}))
+	assert.NoError(t, ledger.Record("chat", &UserBannedEvent{
+		BannedUserID: "troll", BanType: "permanent", ModeratorID: "mod-b", Timestamp: start,
+	}))
+	assert.NoError(t, ledger.recordIssued("chat", "troll", "bot", 0))
+	ledger.now = func() time.Time { return start.Add(2 * time.Minute) }
+	assert.NoError(t, ledger.recordIssued("chat", "spammer", "bot", 0))
+	assert.NoError(t, ledger.Record("chat", &UserBannedEvent{
+		BannedUserID: "spammer", BanType: "permanent", ModeratorID: "bot", Timestamp: start.Add(2*time.Minute + time.Second),
+	}))
+
+	byUser, err := ledger.ByUser("spammer")
+	assert.NoError(t, err)
+	if assert.Len(t, byUser, 2) {
+		assert.Equal(t, BanObserved, byUser[0].Source)
+		assert.Equal(t, start.Add(5*time.Minute), byUser[0].ExpiresAt)
+		assert.Equal(t, BanIssued, byUser[1].Source)
+		assert.Equal(t, "permanent", byUser[1].BanType)
+		assert.Equal(t, "bot", byUser[1].ModeratorID)
+	}
+	byModerator, err := ledger.ByModerator("bot")
+	assert.NoError(t, err)
+	assert.Len(t, byModerator, 1, "the bans issued and observed within a minute are recorded once")
+	byModerator, err = ledger.ByModerator("mod-b")
+	assert.NoError(t, err)
+	if assert.Len(t, byModerator, 1) {
+		assert.Equal(t, "troll", byModerator[0].BannedUserID)
+		assert.Equal(t, BanObserved, byModerator[0].Source, "the first record is kept")
+	}
+
+	active, err := ledger.Active(start.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, active, 3)
+	active, err = ledger.Active(start.Add(5 * time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, active, 2, "the temporary ban expired")
+}
+
+func TestBanLedger_MergesIssuedAndObserved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.jsonl")
+	store, err := OpenFileBanStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ledger := NewBanLedger(store)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ledger.now = func() time.Time { return start }
+
+	assert.NoError(t, ledger.recordIssued("chat", "spammer", "bot", 0))
+	assert.NoError(t, ledger.Record("chat", &UserBannedEvent{
+		BannedUserID: "spammer", BannedUserDisplayName: "Spammer", BanType: "permanent",
+		ModeratorID: "bot", ModeratorDisplayName: "Bot", Timestamp: start.Add(time.Second),
+	}))
+	want := []BanRecord{{
+		LiveChatID: "chat", Source: BanIssued, BannedUserID: "spammer", BannedUserDisplayName: "Spammer",
+		ModeratorID: "bot", ModeratorDisplayName: "Bot", BanType: "permanent", Timestamp: start,
+	}}
+	bans, err := ledger.Bans()
+	assert.NoError(t, err)
+	assert.Equal(t, want, bans, "the observed display names are added to the issued ban")
+	assert.NoError(t, store.Close())
+
+	store, err = OpenFileBanStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+	bans, err = store.Bans()
+	assert.NoError(t, err)
+	assert.Equal(t, want, bans, "the update replaces the record when the file is read")
+}
+
+func TestFileBanStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.jsonl")
+	store, err := OpenFileBanStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	record := BanRecord{LiveChatID: "chat", Source: BanIssued, BannedUserID: "spammer", BanType: "permanent", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	assert.NoError(t, store.AddBan(record))
+	assert.NoError(t, store.Close())
+
+	store, err = OpenFileBanStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+	bans, err := store.Bans()
+	assert.NoError(t, err)
+	assert.Equal(t, []BanRecord{record}, bans)
+}
+
+func TestWriteBans(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	records := []BanRecord{{
+		LiveChatID: "chat", Source: BanObserved, BannedUserID: "spammer", BannedUserDisplayName: "Spam, Inc",
+		ModeratorID: "mod", ModeratorDisplayName: "Mod", BanType: "temporary", Duration: 5 * time.Minute,
+		Timestamp: ts, ExpiresAt: ts.Add(5 * time.Minute),
+	}}
+
+	var csv bytes.Buffer
+	assert.NoError(t, WriteBansCSV(&csv, records))
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, strings.Join(banCSVHeader, ","), lines[0])
+		assert.Equal(t, `2024-05-01T12:00:00Z,chat,observed,spammer,"Spam, Inc",mod,Mod,temporary,300,2024-05-01T12:05:00Z`, lines[1])
+	}
+
+	var data bytes.Buffer
+	assert.NoError(t, WriteBansJSON(&data, records))
+	var decoded []BanRecord
+	assert.NoError(t, json.Unmarshal(data.Bytes(), &decoded))
+	assert.Equal(t, records, decoded)
+
+	data.Reset()
+	assert.NoError(t, WriteBansJSON(&data, nil))
+	assert.Equal(t, "[]\n", data.String())
+}
+
+func TestYouTubeLive_WithBanLedger(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	ledger := NewBanLedger(NewMemoryBanStore())
+	yt := newTestYouTubeLive(t, fake, WithBanLedger(ledger))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, bot, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	banned := textMessage("ban-1", "mod", "")
+	banned.Snippet.Type = "userBannedEvent"
+	banned.Snippet.AuthorChannelId = "channel-mod"
+	banned.Snippet.TextMessageDetails = nil
+	banned.Snippet.UserBannedDetails = &youtube.LiveChatUserBannedMessageDetails{
+		BanType:            "TEMPORARY",
+		BanDurationSeconds: 60,
+		BannedUserDetails:  &youtube.ChannelProfileDetails{ChannelId: "channel-spammer", DisplayName: "spammer"},
+	}
+	fake.queue(banned)
+	bot <- BotBanUser{ChannelID: "channel-troll"}
+
+	assert.Eventually(t, func() bool {
+		bans, _ := ledger.Bans()
+		return len(bans) == 2
+	}, 2*time.Second, 5*time.Millisecond)
+	observed, err := ledger.ByModerator("channel-mod")
+	assert.NoError(t, err)
+	if assert.Len(t, observed, 1) {
+		assert.Equal(t, "channel-spammer", observed[0].BannedUserID)
+		assert.Equal(t, testLiveChatID, observed[0].LiveChatID)
+		assert.Equal(t, time.Minute, observed[0].Duration)
+	}
+	issued, err := ledger.ByUser("channel-troll")
+	assert.NoError(t, err)
+	if assert.Len(t, issued, 1) {
+		assert.Equal(t, BanIssued, issued[0].Source)
+		assert.Equal(t, testBotChannelID, issued[0].ModeratorID)
+	}
+
+	observedIssued := textMessage("ban-2", "bot", "")
+	observedIssued.Snippet.Type = "userBannedEvent"
+	observedIssued.Snippet.TextMessageDetails = nil
+	observedIssued.Snippet.UserBannedDetails = &youtube.LiveChatUserBannedMessageDetails{
+		BanType:           "PERMANENT",
+		BannedUserDetails: &youtube.ChannelProfileDetails{ChannelId: "channel-troll"},
+	}
+	fake.queue(observedIssued)
+	for event := range events {
+		if e, ok := event.(*UserBannedEvent); ok && e.BannedUserID == "channel-troll" {
+			break
+		}
+	}
+	issued, err = ledger.ByUser("channel-troll")
+	assert.NoError(t, err)
+	assert.Len(t, issued, 1, "the issued ban is not recorded again when observed")
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	yt "github.com/steampoweredtaco/youtubelive"
+)
+
+func runLogin(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	if err := parse(fs, args, 0, ""); err != nil {
+		return err
+	}
+	var stored error
+	ytLive, err := cfg.client(yt.OnNewRefreshToken(func(refreshToken string) {
+		stored = cfg.storeToken(refreshToken)
+	}))
+	if err != nil {
+		return err
+	}
+	if err := ytLive.ForceLoginContext(ctx); err != nil {
+		return err
+	}
+	if stored != nil {
+		return fmt.Errorf("could not store the refresh token: %w", stored)
+	}
+	path, _ := cfg.tokenPath()
+	fmt.Println("refresh token stored in", path)
+	return printWhoami(ctx, ytLive)
+}
+
+func runWhoami(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("whoami", flag.ContinueOnError)
+	if err := parse(fs, args, 0, ""); err != nil {
+		return err
+	}
+	ytLive, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	return printWhoami(ctx, ytLive)
+}
+
+func printWhoami(ctx context.Context, ytLive *yt.YouTubeLive) error {
+	name, channelID, err := ytLive.LoggedInChannelContext(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s (%s)\n", name, channelID)
+	return nil
+}
+
+func runLive(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("live", flag.ContinueOnError)
+	verbose := fs.Bool("v", false, "print how the broadcast was found to stderr")
+	noSearch := fs.Bool("no-search", false, "only check the recent uploads, without the 100 quota unit search")
+	if err := parse(fs, args, 1, "[-v] [-no-search] <@handle|channelID>"); err != nil {
+		return err
+	}
+	var options []yt.Option
+	if *noSearch {
+		options = append(options, yt.NoSearchFallback())
+	}
+	ytLive, err := cfg.client(options...)
+	if err != nil {
+		return err
+	}
+	channelID, err := resolveChannel(ctx, ytLive, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	broadcast, err := ytLive.FindLiveBroadcast(ctx, channelID)
+	if *verbose {
+		strategy := string(broadcast.Strategy)
+		if strategy == "" {
+			strategy = "none"
+		}
+		fmt.Fprintf(os.Stderr, "strategy: %s, requests: %d\n", strategy, broadcast.Requests)
+	}
+	if errors.Is(err, yt.NotLiveError) {
+		return errNotLive
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("https://www.youtube.com/watch?v=" + broadcast.BroadcastID)
+	return nil
+}
+
+func runTail(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format, text or json with one event envelope per line")
+	stream := fs.Bool("stream", false, "receive the chat with liveChatMessages.streamList instead of polling")
+	if err := parse(fs, args, 1, "[-format text|json] [-stream] <@handle|videoID>"); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	var options []yt.Option
+	if *stream {
+		options = append(options, yt.StreamChat())
+	}
+	ytLive, err := cfg.client(options...)
+	if err != nil {
+		return err
+	}
+	broadcastID, err := resolveBroadcast(ctx, ytLive, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	events, _, err := ytLive.Attach(ctx, broadcastID)
+	if err != nil {
+		return err
+	}
+	for event := range events {
+		if *format == "json" {
+			data, err := yt.MarshalEvent(event)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			continue
+		}
+		fmt.Println(formatEvent(event))
+	}
+	return nil
+}
+
+func runSay(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("say", flag.ContinueOnError)
+	if err := parse(fs, args, 2, "<@handle|videoID> <message...>"); err != nil {
+		return err
+	}
+	ytLive, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	liveChatID, err := resolveLiveChat(ctx, ytLive, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return ytLive.SendChatMessageContext(ctx, liveChatID, strings.Join(fs.Args()[1:], " "))
+}
+
+func runDelete(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	if err := parse(fs, args, 1, "<messageID>"); err != nil {
+		return err
+	}
+	ytLive, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	return ytLive.DeleteChatMessageContext(ctx, fs.Arg(0))
+}
+
+func runBan(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("ban", flag.ContinueOnError)
+	duration := fs.Duration("duration", 0, "length of a temporary ban, the ban is permanent when 0")
+	if err := parse(fs, args, 2, "[-duration 5m] <@handle|videoID> <channelID>"); err != nil {
+		return err
+	}
+	ytLive, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	liveChatID, err := resolveLiveChat(ctx, ytLive, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return ytLive.BanUserContext(ctx, liveChatID, fs.Arg(1), *duration)
+}
+
+func runWatch(ctx context.Context, cfg *config, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", time.Minute, "time between live checks")
+	if err := parse(fs, args, 1, "[-interval 1m] <@handle|channelID>"); err != nil {
+		return err
+	}
+	ytLive, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	channelID, err := resolveChannel(ctx, ytLive, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	var (
+		checked bool
+		wasLive bool
+	)
+	for {
+		isLive, err := ytLive.IsLiveContext(ctx, channelID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !checked || isLive != wasLive {
+			state := "offline"
+			if isLive {
+				state = "live"
+			}
+			fmt.Printf("[%s] %s is %s\n", time.Now().Format(time.Stamp), fs.Arg(0), state)
+		}
+		checked, wasLive = true, isLive
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolveChannel returns the channel ID of a @handle, other targets are channel IDs.
+func resolveChannel(ctx context.Context, ytLive *yt.YouTubeLive, target string) (string, error) {
+	if strings.HasPrefix(target, "@") {
+		return ytLive.ChannelIDFromChannelHandleContext(ctx, target)
+	}
+	return target, nil
+}
+
+// resolveBroadcast returns the current broadcast of a @handle, other targets are video IDs.
+func resolveBroadcast(ctx context.Context, ytLive *yt.YouTubeLive, target string) (string, error) {
+	if strings.HasPrefix(target, "@") {
+		return ytLive.CurrentBroadcastIDFromChannelHandleContext(ctx, target)
+	}
+	return target, nil
+}
+
+func resolveLiveChat(ctx context.Context, ytLive *yt.YouTubeLive, target string) (string, error) {
+	broadcastID, err := resolveBroadcast(ctx, ytLive, target)
+	if err != nil {
+		return "", err
+	}
+	return ytLive.LiveChatIDContext(ctx, broadcastID)
+}
+
+func formatEvent(event yt.LiveEvent) string {
+	ts, _ := yt.EventTimestamp(event)
+	prefix := fmt.Sprintf("[%s]", ts.Local().Format(time.Stamp))
+	switch e := event.(type) {
+	case *yt.ChatMessageEvent:
+		return fmt.Sprintf("%s %s: %s", prefix, e.DisplayName, e.Message)
+	case *yt.SuperChatEvent:
+		return fmt.Sprintf("%s Super Chat from %s: %s (%.2f %s)", prefix, e.DisplayName, e.Message, e.Amount, e.Currency)
+	case *yt.SuperStickerEvent:
+		return fmt.Sprintf("%s Super Sticker from %s: %s (%.2f %s)", prefix, e.DisplayName, e.StickerID, e.Amount, e.Currency)
+	case *yt.MemberMilestoneEvent:
+		return fmt.Sprintf("%s %s member for %d months: %s", prefix, e.Level, e.Months, e.DisplayName)
+	case *yt.NewMemberEvent:
+		return fmt.Sprintf("%s new %s member: %s", prefix, e.Level, e.DisplayName)
+	case *yt.MembershipGiftEvent:
+		return fmt.Sprintf("%s %s gifted %d %s memberships", prefix, e.DisplayName, e.Total, e.Tier)
+	case *yt.MembershipGiftReceivedEvent:
+		return fmt.Sprintf("%s %s", prefix, e.DisplayText)
+	case *yt.UserBannedEvent:
+		return fmt.Sprintf("%s %s banned %s (%s %v)", prefix, e.ModeratorDisplayName, e.BannedUserDisplayName, e.BanType, e.Duration)
+	case *yt.MessageDeletedEvent:
+		return fmt.Sprintf("%s %s deleted message %s", prefix, e.ModeratorDisplayName, e.DeletedMessageID)
+	case *yt.ChatEndedEvent:
+		return fmt.Sprintf("%s live chat has ended", prefix)
+	case *yt.ErrorEvent:
+		return fmt.Sprintf("%s error: %v", prefix, e.Error)
+	}
+	if name, ok := yt.EventTypeName(event); ok {
+		return fmt.Sprintf("%s %s", prefix, name)
+	}
+	return fmt.Sprintf("%s %T", prefix, event)
+}
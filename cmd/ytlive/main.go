@@ -0,0 +1,203 @@
+// Command ytlive logs in to YouTube, checks live status, tails live chats and sends
+// chat messages and moderation actions from the command line.
+//
+// The credentials are read from flags, the YTLIVE_* environment variables or a .env file
+// in the working directory, in that order. Run ytlive without arguments for the usage.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	yt "github.com/steampoweredtaco/youtubelive"
+)
+
+// errNotLive makes live exit with status 1 without printing an error.
+var errNotLive = errors.New("not live")
+
+type command struct {
+	name  string
+	args  string
+	usage string
+	run   func(ctx context.Context, cfg *config, args []string) error
+}
+
+var commands = []command{
+	{"login", "", "log in with the browser and store the refresh token", runLogin},
+	{"whoami", "", "show the logged-in channel", runWhoami},
+	{"live", "[-v] [-no-search] <@handle|channelID>", "print the live broadcast, exits with status 1 when not live", runLive},
+	{"tail", "[-format text|json] [-stream] <@handle|videoID>", "print the live chat events", runTail},
+	{"say", "<@handle|videoID> <message...>", "send a chat message", runSay},
+	{"delete", "<messageID>", "delete a chat message", runDelete},
+	{"ban", "[-duration 5m] <@handle|videoID> <channelID>", "ban a channel from the live chat, permanently without -duration", runBan},
+	{"watch", "[-interval 1m] <@handle|channelID>", "print when the channel goes live or offline", runWatch},
+}
+
+func main() {
+	cfg := &config{}
+	global := flag.NewFlagSet("ytlive", flag.ExitOnError)
+	cfg.register(global)
+	global.Usage = func() { usage(global) }
+	_ = global.Parse(os.Args[1:])
+	env, _ := yt.ReadFromDotFile()
+	cfg.applyEnv(global, os.LookupEnv, env)
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+
+	name, args := global.Arg(0), global.Args()[1:]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := cmd.run(ctx, cfg, args)
+		stop()
+		switch {
+		case errors.Is(err, errNotLive):
+			os.Exit(1)
+		case errors.Is(err, flag.ErrHelp):
+			os.Exit(2)
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "ytlive:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "ytlive: unknown command %q\n", name)
+	global.Usage()
+	os.Exit(2)
+}
+
+func usage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintln(out, "Usage: ytlive [flags] <command> [command flags] [arguments]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %s %s\n    \t%s\n", cmd.name, cmd.args, cmd.usage)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	global.PrintDefaults()
+}
+
+// config holds the credentials shared by the commands.
+type config struct {
+	clientID     string
+	clientSecret string
+	refreshToken string
+	apiKey       string
+	tokenFile    string
+	listenAddr   string
+}
+
+// envFallbacks are the environment variables and .env keys of the flags that are not given
+// on the command line. They are applied after parsing so the credentials are never shown
+// as flag defaults in the usage.
+var envFallbacks = []struct {
+	flag, env, dotEnv string
+}{
+	{"client-id", "YTLIVE_CLIENT_ID", "client_id"},
+	{"client-secret", "YTLIVE_CLIENT_SECRET", "client_secret"},
+	{"refresh-token", "YTLIVE_REFRESH_TOKEN", "refresh_token"},
+	{"api-key", "YTLIVE_API_KEY", "api_key"},
+	{"token-file", "YTLIVE_TOKEN_FILE", "token_file"},
+}
+
+func (c *config) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.clientID, "client-id", "", "OAuth2 client ID (env YTLIVE_CLIENT_ID)")
+	fs.StringVar(&c.clientSecret, "client-secret", "", "OAuth2 client secret (env YTLIVE_CLIENT_SECRET)")
+	fs.StringVar(&c.refreshToken, "refresh-token", "", "refresh token, the stored token from login is used when empty (env YTLIVE_REFRESH_TOKEN)")
+	fs.StringVar(&c.apiKey, "api-key", "", "API key used for read only requests (env YTLIVE_API_KEY)")
+	fs.StringVar(&c.tokenFile, "token-file", "", "file the refresh token is stored in by login (env YTLIVE_TOKEN_FILE)")
+	fs.StringVar(&c.listenAddr, "listen", "127.0.0.1:0", "address of the local OAuth2 redirect listener used by login")
+}
+
+// applyEnv sets the flags that were not given from the environment, or else from the .env
+// values.
+func (c *config) applyEnv(fs *flag.FlagSet, lookupEnv func(string) (string, bool), dotEnv map[string]string) {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	for _, fallback := range envFallbacks {
+		if given[fallback.flag] {
+			continue
+		}
+		v, ok := lookupEnv(fallback.env)
+		if !ok {
+			v, ok = dotEnv[fallback.dotEnv]
+		}
+		if ok {
+			_ = fs.Set(fallback.flag, v)
+		}
+	}
+}
+
+func (c *config) tokenPath() (string, error) {
+	if c.tokenFile != "" {
+		return c.tokenFile, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ytlive", "refresh_token"), nil
+}
+
+func (c *config) storedToken() string {
+	path, err := c.tokenPath()
+	if err != nil {
+		return ""
+	}
+	token, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(token))
+}
+
+func (c *config) storeToken(token string) error {
+	path, err := c.tokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(token+"\n"), 0o600)
+}
+
+// client creates the YouTubeLive client, with the API key for read only requests when one
+// is configured.
+func (c *config) client(options ...yt.Option) (*yt.YouTubeLive, error) {
+	refreshToken := c.refreshToken
+	if refreshToken == "" {
+		refreshToken = c.storedToken()
+	}
+	options = append([]yt.Option{yt.RefreshToken(refreshToken), yt.OathListenAddr(c.listenAddr)}, options...)
+	if c.apiKey != "" {
+		options = append(options, yt.APIKey(c.apiKey))
+	}
+	return yt.NewYouTubeLive(c.clientID, c.clientSecret, options...)
+}
+
+// parse parses the command flags and checks the number of arguments.
+func parse(fs *flag.FlagSet, args []string, minArgs int, usage string) error {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ytlive %s %s\n", fs.Name(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < minArgs {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	return nil
+}
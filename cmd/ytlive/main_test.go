@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_UsageHidesCredentials(t *testing.T) {
+	cfg := &config{}
+	fs := flag.NewFlagSet("ytlive", flag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	cfg.register(fs)
+	assert.NoError(t, fs.Parse(nil))
+	env := map[string]string{"YTLIVE_CLIENT_SECRET": "GOCSPX-secret", "YTLIVE_REFRESH_TOKEN": "1//secret-refresh"}
+	cfg.applyEnv(fs, func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}, map[string]string{"api_key": "dot-key"})
+	usage(fs)
+
+	assert.NotContains(t, out.String(), "GOCSPX-secret")
+	assert.NotContains(t, out.String(), "1//secret-refresh")
+	assert.NotContains(t, out.String(), "dot-key")
+	assert.Equal(t, "GOCSPX-secret", cfg.clientSecret)
+	assert.Equal(t, "1//secret-refresh", cfg.refreshToken)
+	assert.Equal(t, "dot-key", cfg.apiKey)
+}
+
+func TestConfig_ApplyEnvPrecedence(t *testing.T) {
+	cfg := &config{}
+	fs := flag.NewFlagSet("ytlive", flag.ContinueOnError)
+	cfg.register(fs)
+	assert.NoError(t, fs.Parse([]string{"-client-id", "flag-id", "-api-key", ""}))
+	env := map[string]string{"YTLIVE_CLIENT_ID": "env-id", "YTLIVE_CLIENT_SECRET": "env-secret", "YTLIVE_API_KEY": "env-key"}
+	dotEnv := map[string]string{"client_secret": "dot-secret", "token_file": "dot-file"}
+	cfg.applyEnv(fs, func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}, dotEnv)
+
+	assert.Equal(t, "flag-id", cfg.clientID)
+	assert.Equal(t, "env-secret", cfg.clientSecret)
+	assert.Empty(t, cfg.apiKey, "an explicitly empty flag is kept")
+	assert.Equal(t, "dot-file", cfg.tokenFile)
+	assert.Empty(t, cfg.refreshToken)
+}
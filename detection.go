@@ -0,0 +1,157 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// DetectionStrategy is how FindLiveBroadcast found the live broadcast of a channel.
+type DetectionStrategy string
+
+const (
+	// DetectedInUploads found the broadcast in the recent uploads of the channel, with a
+	// playlistItems.list and a single videos.list for all of them, 3 quota units in total.
+	DetectedInUploads DetectionStrategy = "uploads"
+	// DetectedBySearch found the broadcast with search.list, 100 quota units, after the
+	// recent uploads had no live broadcast. See NoSearchFallback.
+	DetectedBySearch DetectionStrategy = "search"
+)
+
+// LiveBroadcast is the live broadcast of a channel found by FindLiveBroadcast.
+type LiveBroadcast struct {
+	BroadcastID string
+	ChannelID   string
+	Strategy    DetectionStrategy
+	// Requests is the number of API requests made to find the broadcast.
+	Requests int
+}
+
+// FindLiveBroadcast returns the live broadcast of the channel and how it was found. The
+// recent uploads of the channel are checked first and search.list is the fallback, unless
+// disabled with NoSearchFallback. Will return NotLiveError when no live broadcast is found,
+// the returned LiveBroadcast still counts the requests made.
+func (yt *YouTubeLive) FindLiveBroadcast(ctx context.Context, channelID string) (LiveBroadcast, error) {
+	broadcast := LiveBroadcast{ChannelID: channelID}
+	videoID, err := yt.findLiveUpload(ctx, channelID, &broadcast.Requests)
+	if err != nil {
+		return broadcast, err
+	}
+	if videoID != "" {
+		broadcast.BroadcastID, broadcast.Strategy = videoID, DetectedInUploads
+		return broadcast, nil
+	}
+	if !yt.searchFallback {
+		return broadcast, NotLiveError
+	}
+
+	// The uploads work most of the time and are much cheaper than a search, but a
+	// broadcast is sometimes missing from them, such as for subscriber only chat when the
+	// authenticated user is not the owner. Search does not always find those either and
+	// there is no known workaround through the API.
+	var qResp *youtube.SearchListResponse
+	broadcast.Requests++
+	err = yt.readPublic("search.list", func(service *youtube.Service) (err error) {
+		qResp, err = service.Search.List([]string{"snippet", "id"}).
+			ChannelId(channelID).
+			EventType("live").
+			Q(channelID).
+			Type("video").
+			Fields(searchVideoFields).
+			Context(ctx).
+			Do()
+		return err
+	})
+	if err != nil {
+		return broadcast, fmt.Errorf("failed to get search results: %w", err)
+	}
+	if len(qResp.Items) == 0 {
+		return broadcast, NotLiveError
+	}
+	broadcast.BroadcastID, broadcast.Strategy = qResp.Items[0].Id.VideoId, DetectedBySearch
+	return broadcast, nil
+}
+
+// findLiveUpload returns the most recent live video of the uploads of the channel, or ""
+// when none of them is live. requests is incremented for every API request.
+func (yt *YouTubeLive) findLiveUpload(ctx context.Context, channelID string, requests *int) (string, error) {
+	var channelsResp *youtube.ChannelListResponse
+	*requests++
+	err := yt.readPublic("channels.list", func(service *youtube.Service) (err error) {
+		channelsResp, err = service.Channels.List([]string{"contentDetails"}).
+			Id(channelID).
+			Fields(uploadsPlaylistFields).
+			Context(ctx).
+			Do()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel details: %w", err)
+	}
+	if len(channelsResp.Items) == 0 {
+		return "", ErrChannelNotFound
+	}
+	uploadsPlaylist := channelsResp.Items[0].ContentDetails.RelatedPlaylists.Uploads
+
+	var playlistResp *youtube.PlaylistItemListResponse
+	*requests++
+	err = yt.readPublic("playlistItems.list", func(service *youtube.Service) (err error) {
+		playlistResp, err = service.PlaylistItems.List([]string{"contentDetails"}).
+			PlaylistId(uploadsPlaylist).
+			MaxResults(maxVideoIDs).
+			Fields(playlistVideoFields).
+			Context(ctx).
+			Do()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get uploads: %w", err)
+	}
+
+	videoIDs := make([]string, 0, len(playlistResp.Items))
+	for _, item := range playlistResp.Items {
+		videoIDs = append(videoIDs, item.ContentDetails.VideoId)
+	}
+	if len(videoIDs) == 0 {
+		return "", nil
+	}
+
+	// Playlist items do not tell whether a video is live, so the uploads are looked up in
+	// one videos.list; a page never holds more uploads than videos.list accepts.
+	var videoResp *youtube.VideoListResponse
+	*requests++
+	err = yt.readPublic("videos.list", func(service *youtube.Service) (err error) {
+		videoResp, err = service.Videos.List([]string{"snippet", "liveStreamingDetails"}).
+			Id(videoIDs...).
+			Fields(liveVideoFields).
+			Context(ctx).
+			Do()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get upload details: %w", err)
+	}
+	live := make(map[string]bool, len(videoResp.Items))
+	for _, video := range videoResp.Items {
+		live[video.Id] = isLiveVideo(video)
+	}
+	// The uploads are the most recent first, so is the live video found.
+	for _, videoID := range videoIDs {
+		if live[videoID] {
+			return videoID, nil
+		}
+	}
+	return "", nil
+}
+
+// isLiveVideo reports whether the video is a live broadcast that is on air. The
+// liveBroadcastContent of the snippet is checked first, it is "none" for videos and ended
+// broadcasts and "upcoming" for scheduled ones.
+func isLiveVideo(video *youtube.Video) bool {
+	if video.Snippet != nil && video.Snippet.LiveBroadcastContent != "" {
+		return video.Snippet.LiveBroadcastContent == "live"
+	}
+	details := video.LiveStreamingDetails
+	return details != nil && details.ActualStartTime != "" && details.ActualEndTime == ""
+}
@@ -0,0 +1,75 @@
+package youtubelive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestYouTubeLive_FindLiveBroadcast(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addUpload("channel", "vod")
+	fake.addUpload("channel", "stream")
+	fake.addLiveVideo("stream", "chat")
+	fake.addUpload("channel", "newer-vod")
+	yt := newTestYouTubeLive(t, fake)
+
+	broadcast, err := yt.FindLiveBroadcast(context.Background(), "channel")
+	assert.NoError(t, err)
+	assert.Equal(t, LiveBroadcast{BroadcastID: "stream", ChannelID: "channel", Strategy: DetectedInUploads, Requests: 3}, broadcast)
+	assert.Zero(t, fake.callCount("/youtube/v3/search"))
+}
+
+func TestYouTubeLive_FindLiveBroadcastSearch(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addUpload("UC-hidden", "vod")
+	// The live video is not in the uploads of the channel.
+	fake.addLiveVideo("hidden", "chat")
+
+	broadcast, err := newTestYouTubeLive(t, fake).FindLiveBroadcast(context.Background(), "UC-hidden")
+	assert.NoError(t, err)
+	assert.Equal(t, LiveBroadcast{BroadcastID: "hidden", ChannelID: "UC-hidden", Strategy: DetectedBySearch, Requests: 4}, broadcast)
+
+	broadcast, err = newTestYouTubeLive(t, fake, NoSearchFallback()).FindLiveBroadcast(context.Background(), "UC-hidden")
+	assert.ErrorIs(t, err, NotLiveError)
+	assert.Equal(t, 3, broadcast.Requests)
+	assert.Equal(t, 1, fake.callCount("/youtube/v3/search"))
+}
+
+func TestYouTubeLive_FindLiveBroadcastVideosError(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addUpload("channel", "stream")
+	fake.addLiveVideo("stream", "chat")
+	fake.fail("/youtube/v3/videos", ReasonQuotaExceeded)
+
+	broadcast, err := newTestYouTubeLive(t, fake).FindLiveBroadcast(context.Background(), "channel")
+	assert.True(t, IsQuotaExceeded(err), "got %v", err)
+	assert.NotErrorIs(t, err, NotLiveError)
+	assert.Empty(t, broadcast.Strategy)
+	assert.Zero(t, fake.callCount("/youtube/v3/search"), "errors do not fall back to search")
+}
+
+func TestIsLiveVideo(t *testing.T) {
+	onAir := &youtube.VideoLiveStreamingDetails{ActualStartTime: "2024-01-01T00:00:00Z"}
+	ended := &youtube.VideoLiveStreamingDetails{ActualStartTime: "2024-01-01T00:00:00Z", ActualEndTime: "2024-01-01T01:00:00Z"}
+	tests := []struct {
+		name  string
+		video *youtube.Video
+		want  bool
+	}{
+		{"live", &youtube.Video{Snippet: &youtube.VideoSnippet{LiveBroadcastContent: "live"}}, true},
+		{"video", &youtube.Video{Snippet: &youtube.VideoSnippet{LiveBroadcastContent: "none"}}, false},
+		{"snippet first", &youtube.Video{Snippet: &youtube.VideoSnippet{LiveBroadcastContent: "none"}, LiveStreamingDetails: onAir}, false},
+		{"upcoming", &youtube.Video{Snippet: &youtube.VideoSnippet{LiveBroadcastContent: "upcoming"}}, false},
+		{"details on air", &youtube.Video{LiveStreamingDetails: onAir}, true},
+		{"details ended", &youtube.Video{LiveStreamingDetails: ended}, false},
+		{"no details", &youtube.Video{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isLiveVideo(tt.video))
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime/debug"
+	"sync"
+)
+
+type DispatcherOption func(d *Dispatcher)
+
+// DispatchConcurrency sets how many handlers may run at once, the default is 1 which
+// handles the events one at a time in the order they are received. With more than one,
+// handlers of different events may run out of order.
+func DispatchConcurrency(n int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.concurrency = max(n, 1)
+	}
+}
+
+// DispatcherLogger sets the logger used for recovered panics, the default is
+// slog.Default().
+func DispatcherLogger(log *slog.Logger) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.log = log
+	}
+}
+
+// OnPanic sets a function called with the event and recovered value when a handler panics,
+// the panic is always logged.
+func OnPanic(handler func(event LiveEvent, recovered any)) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.onPanic = handler
+	}
+}
+
+// Dispatcher calls the handlers registered for the type of each event received from Attach,
+// as an alternative to a type switch. Handlers are registered with the On methods or with
+// On for event types of other packages.
+type Dispatcher struct {
+	concurrency int
+	log         *slog.Logger
+	onPanic     func(event LiveEvent, recovered any)
+
+	mu        sync.RWMutex
+	handlers  map[reflect.Type][]func(event LiveEvent)
+	all       []func(event LiveEvent)
+	unhandled []func(event LiveEvent)
+}
+
+func NewDispatcher(options ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		concurrency: 1,
+		log:         slog.Default(),
+		handlers:    make(map[reflect.Type][]func(event LiveEvent)),
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// On registers a handler for events of type E, such as On(d, func(e *ChatMessageEvent) {}).
+// Events from Attach are always pointers.
+func On[E LiveEvent](d *Dispatcher, handler func(event E)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := reflect.TypeFor[E]()
+	d.handlers[t] = append(d.handlers[t], func(event LiveEvent) {
+		handler(event.(E))
+	})
+}
+
+// OnChatMessage registers a handler called for every ChatMessageEvent.
+func (d *Dispatcher) OnChatMessage(handler func(event *ChatMessageEvent)) {
+	On(d, handler)
+}
+
+// OnSuperChat registers a handler called for every SuperChatEvent.
+func (d *Dispatcher) OnSuperChat(handler func(event *SuperChatEvent)) {
+	On(d, handler)
+}
+
+// OnSuperSticker registers a handler called for every SuperStickerEvent.
+func (d *Dispatcher) OnSuperSticker(handler func(event *SuperStickerEvent)) {
+	On(d, handler)
+}
+
+// OnMemberMilestone registers a handler called for every MemberMilestoneEvent.
+func (d *Dispatcher) OnMemberMilestone(handler func(event *MemberMilestoneEvent)) {
+	On(d, handler)
+}
+
+// OnMembershipGift registers a handler called for every MembershipGiftEvent.
+func (d *Dispatcher) OnMembershipGift(handler func(event *MembershipGiftEvent)) {
+	On(d, handler)
+}
+
+// OnMembershipGiftReceived registers a handler called for every MembershipGiftReceivedEvent.
+func (d *Dispatcher) OnMembershipGiftReceived(handler func(event *MembershipGiftReceivedEvent)) {
+	On(d, handler)
+}
+
+// OnNewMember registers a handler called for every NewMemberEvent.
+func (d *Dispatcher) OnNewMember(handler func(event *NewMemberEvent)) {
+	On(d, handler)
+}
+
+// OnBan registers a handler called for every UserBannedEvent.
+func (d *Dispatcher) OnBan(handler func(event *UserBannedEvent)) {
+	On(d, handler)
+}
+
+// OnMessageDeleted registers a handler called for every MessageDeletedEvent.
+func (d *Dispatcher) OnMessageDeleted(handler func(event *MessageDeletedEvent)) {
+	On(d, handler)
+}
+
+// OnMessageRetracted registers a handler called for every MessageRetractedEvent.
+func (d *Dispatcher) OnMessageRetracted(handler func(event *MessageRetractedEvent)) {
+	On(d, handler)
+}
+
+// OnMembersOnlyMode registers a handler called for every MembersOnlyModeEvent.
+func (d *Dispatcher) OnMembersOnlyMode(handler func(event *MembersOnlyModeEvent)) {
+	On(d, handler)
+}
+
+// OnTombstone registers a handler called for every TombstoneEvent.
+func (d *Dispatcher) OnTombstone(handler func(event *TombstoneEvent)) {
+	On(d, handler)
+}
+
+// OnPoll registers a handler called for every PollEvent.
+func (d *Dispatcher) OnPoll(handler func(event *PollEvent)) {
+	On(d, handler)
+}
+
+// OnUnknown registers a handler called for every UnknownEvent.
+func (d *Dispatcher) OnUnknown(handler func(event *UnknownEvent)) {
+	On(d, handler)
+}
+
+// OnChatEnded registers a handler called for every ChatEndedEvent.
+func (d *Dispatcher) OnChatEnded(handler func(event *ChatEndedEvent)) {
+	On(d, handler)
+}
+
+// OnStreamEnd registers a handler called for every StreamEndEvent.
+func (d *Dispatcher) OnStreamEnd(handler func(event *StreamEndEvent)) {
+	On(d, handler)
+}
+
+// OnFirstMessage registers a handler called for every FirstMessageEvent.
+func (d *Dispatcher) OnFirstMessage(handler func(event *FirstMessageEvent)) {
+	On(d, handler)
+}
+
+// OnEventsDropped registers a handler called for every EventsDroppedEvent.
+func (d *Dispatcher) OnEventsDropped(handler func(event *EventsDroppedEvent)) {
+	On(d, handler)
+}
+
+// OnError registers a handler called for every ErrorEvent.
+func (d *Dispatcher) OnError(handler func(event *ErrorEvent)) {
+	On(d, handler)
+}
+
+// OnAny registers a handler called for every event in addition to the typed handlers.
+func (d *Dispatcher) OnAny(handler func(event LiveEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.all = append(d.all, handler)
+}
+
+// OnUnhandled registers a handler called for events without a typed handler, such as event
+// types added in a later version of this package.
+func (d *Dispatcher) OnUnhandled(handler func(event LiveEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.unhandled = append(d.unhandled, handler)
+}
+
+// Run dispatches the events until the events channel is closed or ctx is done, then waits
+// for the running handlers to return.
+func (d *Dispatcher) Run(ctx context.Context, events <-chan LiveEvent) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	sem := make(chan struct{}, d.concurrency)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if d.concurrency == 1 {
+				d.Dispatch(event)
+				continue
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				d.Dispatch(event)
+			}()
+		}
+	}
+}
+
+// Dispatch calls the handlers of a single event and reports whether a typed handler was
+// registered for it. Panics in handlers are recovered.
+func (d *Dispatcher) Dispatch(event LiveEvent) bool {
+	d.mu.RLock()
+	handlers := d.handlers[reflect.TypeOf(event)]
+	all := d.all
+	unhandled := d.unhandled
+	d.mu.RUnlock()
+
+	for _, handler := range all {
+		d.call(handler, event)
+	}
+	if len(handlers) == 0 {
+		for _, handler := range unhandled {
+			d.call(handler, event)
+		}
+		return false
+	}
+	for _, handler := range handlers {
+		d.call(handler, event)
+	}
+	return true
+}
+
+func (d *Dispatcher) call(handler func(event LiveEvent), event LiveEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.log.Error("event handler panicked", "event", fmt.Sprintf("%T", event), "panic", r, "stack", string(debug.Stack()))
+			if d.onPanic != nil {
+				d.onPanic(event, r)
+			}
+		}
+	}()
+	handler(event)
+}
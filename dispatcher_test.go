@@ -0,0 +1,69 @@
+package youtubelive
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	var panicked []LiveEvent
+	d := NewDispatcher(OnPanic(func(event LiveEvent, recovered any) {
+		panicked = append(panicked, event)
+	}))
+
+	var chats, supers, all int
+	var unhandled []LiveEvent
+	d.OnChatMessage(func(e *ChatMessageEvent) {
+		chats++
+		if e.Message == "boom" {
+			panic("boom")
+		}
+	})
+	d.OnSuperChat(func(e *SuperChatEvent) { supers++ })
+	d.OnAny(func(event LiveEvent) { all++ })
+	d.OnUnhandled(func(event LiveEvent) { unhandled = append(unhandled, event) })
+
+	boom := chatFrom(AuthorDetails{}, "boom")
+	ended := &ChatEndedEvent{}
+	assert.True(t, d.Dispatch(chatFrom(AuthorDetails{}, "hi")))
+	assert.True(t, d.Dispatch(boom))
+	assert.True(t, d.Dispatch(&SuperChatEvent{}))
+	assert.False(t, d.Dispatch(ended))
+
+	assert.Equal(t, 2, chats)
+	assert.Equal(t, 1, supers)
+	assert.Equal(t, 4, all)
+	assert.Equal(t, []LiveEvent{ended}, unhandled)
+	assert.Equal(t, []LiveEvent{boom}, panicked)
+}
+
+func TestDispatcher_RunConcurrently(t *testing.T) {
+	d := NewDispatcher(DispatchConcurrency(3))
+	var running, peak, handled atomic.Int32
+	d.OnChatMessage(func(e *ChatMessageEvent) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		handled.Add(1)
+	})
+
+	events := make(chan LiveEvent, 10)
+	for range 9 {
+		events <- chatFrom(AuthorDetails{}, "hi")
+	}
+	close(events)
+	assert.NoError(t, d.Run(context.Background(), events))
+	assert.Equal(t, int32(9), handled.Load(), "Run waits for running handlers")
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Greater(t, peak.Load(), int32(1))
+}
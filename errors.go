@@ -2,6 +2,11 @@ package youtubelive
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
 )
 
 var (
@@ -11,6 +16,139 @@ var (
 
 	ErrBroadcastNotFound = errors.New("broadcast not found")
 	ErrChatDisabled      = errors.New("live chat disabled")
+	ErrChannelNotFound   = errors.New("channel not found")
+	ErrUserNotFound      = errors.New("user does not exist")
+
+	NotLoggedIn           = errors.New("user not logged in")
+	ErrCredentialsChanged = errors.New("credentials changed during login")
+	// errTokenSource marks the errors of getting an access token, see tokenErrorSource.
+	errTokenSource = errors.New("could not get access token")
+
+	ErrInvalidCredential    = errors.New("credential requires a refresh token or api key")
+	ErrCredentialsExhausted = errors.New("all read credentials exceeded their quota")
+	ErrInvalidPollInterval  = errors.New("minimum poll interval is above the maximum")
+
+	ErrHubClosed       = errors.New("hub is closed")
+	ErrHubStarted      = errors.New("hub is already running")
+	ErrAlreadyAttached = errors.New("chat is already attached")
+	ErrNotAttached     = errors.New("chat is not attached")
+
+	ErrUnknownCurrency     = errors.New("no exchange rate for currency")
+	ErrInvalidExchangeRate = errors.New("exchange rate must be a positive decimal number")
 
-	NotLoggedIn = errors.New("user not logged in")
+	ErrCommandExists  = errors.New("command already registered")
+	ErrInvalidCommand = errors.New("command needs a name and a handler")
+
+	ErrUnknownEventType    = errors.New("unknown event type")
+	ErrUnsupportedEnvelope = errors.New("unsupported event envelope version")
+)
+
+// Error reasons returned by the YouTube API that callers commonly need to handle.
+const (
+	ReasonQuotaExceeded     = "quotaExceeded"
+	ReasonRateLimitExceeded = "rateLimitExceeded"
+	ReasonLiveChatEnded     = "liveChatEnded"
+	ReasonLiveChatNotFound  = "liveChatNotFound"
+	ReasonLiveChatDisabled  = "liveChatDisabled"
+	ReasonForbidden         = "forbidden"
 )
+
+// APIError is returned when a YouTube API request fails. Use errors.As to inspect it or
+// the IsRetryable, IsQuotaExceeded and IsChatEnded helpers to classify it.
+type APIError struct {
+	// Operation is the API method that failed, such as "liveChatMessages.list".
+	Operation string
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Reason is the YouTube error reason, such as ReasonQuotaExceeded, it is blank when
+	// the response did not include one.
+	Reason  string
+	Message string
+	// Retryable is true when the same request may succeed if tried again later.
+	Retryable bool
+	Err       error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("youtube %s failed with status %d", e.Operation, e.StatusCode)
+	if e.Reason != "" {
+		msg += " (" + e.Reason + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes reasons with an existing sentinel error match it.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrChatDisabled:
+		return e.Reason == ReasonLiveChatDisabled
+	case NotLoggedIn:
+		return e.StatusCode == http.StatusUnauthorized
+	}
+	return false
+}
+
+// IsRetryable reports whether the request that returned err may succeed if tried again
+// later, such as when rate limited or on server errors.
+func IsRetryable(err error) bool {
+	apiErr := &APIError{}
+	return errors.As(err, &apiErr) && apiErr.Retryable
+}
+
+// IsQuotaExceeded reports whether err is caused by the daily quota being used up.
+func IsQuotaExceeded(err error) bool {
+	return hasReason(err, ReasonQuotaExceeded)
+}
+
+// IsChatEnded reports whether err is caused by the live chat having ended or no longer
+// existing.
+func IsChatEnded(err error) bool {
+	return hasReason(err, ReasonLiveChatEnded) || hasReason(err, ReasonLiveChatNotFound)
+}
+
+func hasReason(err error, reason string) bool {
+	apiErr := &APIError{}
+	return errors.As(err, &apiErr) && apiErr.Reason == reason
+}
+
+// wrapAPIError converts errors of the API call named by operation into an *APIError, and
+// token errors into NotLoggedIn.
+func wrapAPIError(operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+	retrieveErr := &oauth2.RetrieveError{}
+	if errors.As(err, &retrieveErr) || errors.Is(err, errTokenSource) {
+		return fmt.Errorf("%w: %w", NotLoggedIn, err)
+	}
+	gerr := &googleapi.Error{}
+	if !errors.As(err, &gerr) {
+		return err
+	}
+	apiErr := &APIError{
+		Operation:  operation,
+		StatusCode: gerr.Code,
+		Message:    gerr.Message,
+		Err:        err,
+	}
+	if len(gerr.Errors) > 0 {
+		apiErr.Reason = gerr.Errors[0].Reason
+	}
+	switch {
+	case apiErr.Reason == ReasonRateLimitExceeded, apiErr.Reason == "userRateLimitExceeded",
+		apiErr.Reason == "backendError":
+		apiErr.Retryable = true
+	case apiErr.Reason == ReasonQuotaExceeded:
+		// Quota is only reset daily, retrying will not help.
+	case gerr.Code == http.StatusTooManyRequests, gerr.Code >= http.StatusInternalServerError:
+		apiErr.Retryable = true
+	}
+	return apiErr
+}
@@ -0,0 +1,85 @@
+package youtubelive
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+func googleError(code int, reason string) error {
+	gerr := &googleapi.Error{Code: code, Message: "message"}
+	if reason != "" {
+		gerr.Errors = []googleapi.ErrorItem{{Reason: reason}}
+	}
+	return gerr
+}
+
+func TestWrapAPIError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		retryable    bool
+		quota        bool
+		chatEnded    bool
+		chatDisabled bool
+		notLoggedIn  bool
+	}{
+		{name: "quota", err: googleError(http.StatusForbidden, ReasonQuotaExceeded), quota: true},
+		{name: "rate limit", err: googleError(http.StatusForbidden, ReasonRateLimitExceeded), retryable: true},
+		{name: "server error", err: googleError(http.StatusServiceUnavailable, ""), retryable: true},
+		{name: "chat ended", err: googleError(http.StatusForbidden, ReasonLiveChatEnded), chatEnded: true},
+		{name: "chat not found", err: googleError(http.StatusNotFound, ReasonLiveChatNotFound), chatEnded: true},
+		{name: "chat disabled", err: googleError(http.StatusForbidden, ReasonLiveChatDisabled), chatDisabled: true},
+		{name: "unauthorized", err: googleError(http.StatusUnauthorized, "authError"), notLoggedIn: true},
+		{name: "token", err: fmt.Errorf("request: %w", &oauth2.RetrieveError{ErrorCode: "invalid_grant"}), notLoggedIn: true},
+		{name: "token expired", err: fmt.Errorf(`Get "https://youtube.googleapis.com": %w`, tokenError(errors.New("oauth2: token expired and refresh token is not set"))), notLoggedIn: true},
+		{name: "token network", err: tokenError(&url.Error{Op: "Post", URL: "https://oauth2.googleapis.com/token", Err: errors.New("connection refused")})},
+		{name: "network", err: errors.New("dial tcp: connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapAPIError("liveChatMessages.list", tt.err)
+			assert.Equal(t, tt.retryable, IsRetryable(err))
+			assert.Equal(t, tt.quota, IsQuotaExceeded(err))
+			assert.Equal(t, tt.chatEnded, IsChatEnded(err))
+			assert.Equal(t, tt.chatDisabled, errors.Is(err, ErrChatDisabled))
+			assert.Equal(t, tt.notLoggedIn, errors.Is(err, NotLoggedIn))
+		})
+	}
+}
+
+type failingTokenSource struct {
+	err error
+}
+
+func (s failingTokenSource) Token() (*oauth2.Token, error) {
+	return nil, s.err
+}
+
+// tokenError returns err as returned by the token source of a client.
+func tokenError(err error) error {
+	_, err = tokenErrorSource{failingTokenSource{err}}.Token()
+	return err
+}
+
+func TestAPIError_Error(t *testing.T) {
+	err := wrapAPIError("videos.list", googleError(http.StatusForbidden, ReasonQuotaExceeded))
+	apiErr := &APIError{}
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, "videos.list", apiErr.Operation)
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	}
+	assert.Equal(t, "youtube videos.list failed with status 403 (quotaExceeded): message", err.Error())
+}
+
+func TestYouTubeLive_ChannelIDFromChannelHandleNotFound(t *testing.T) {
+	yt := newTestYouTubeLive(t, newFakeYouTube())
+	_, err := yt.ChannelIDFromChannelHandle("nobody")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
@@ -0,0 +1,163 @@
+package youtubelive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// EventEnvelopeVersion is the version of the envelope written by MarshalEvent. It is
+// increased when a change to the envelope or to an event would break existing decoders.
+const EventEnvelopeVersion = 1
+
+// EventEnvelope is the JSON encoding of a LiveEvent, Type names the event and Data holds
+// its fields. Durations are encoded as nanoseconds and errors as their message.
+type EventEnvelope struct {
+	Version int             `json:"version"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// eventTypes are the envelope type names of every LiveEvent, the names must never change.
+var eventTypes = map[string]func() LiveEvent{
+	"chatMessage":            func() LiveEvent { return &ChatMessageEvent{} },
+	"superChat":              func() LiveEvent { return &SuperChatEvent{} },
+	"superSticker":           func() LiveEvent { return &SuperStickerEvent{} },
+	"memberMilestone":        func() LiveEvent { return &MemberMilestoneEvent{} },
+	"membershipGift":         func() LiveEvent { return &MembershipGiftEvent{} },
+	"membershipGiftReceived": func() LiveEvent { return &MembershipGiftReceivedEvent{} },
+	"newMember":              func() LiveEvent { return &NewMemberEvent{} },
+	"userBanned":             func() LiveEvent { return &UserBannedEvent{} },
+	"messageDeleted":         func() LiveEvent { return &MessageDeletedEvent{} },
+	"messageRetracted":       func() LiveEvent { return &MessageRetractedEvent{} },
+	"membersOnlyMode":        func() LiveEvent { return &MembersOnlyModeEvent{} },
+	"tombstone":              func() LiveEvent { return &TombstoneEvent{} },
+	"poll":                   func() LiveEvent { return &PollEvent{} },
+	"unknown":                func() LiveEvent { return &UnknownEvent{} },
+	"chatEnded":              func() LiveEvent { return &ChatEndedEvent{} },
+	"streamEnd":              func() LiveEvent { return &StreamEndEvent{} },
+	"error":                  func() LiveEvent { return &ErrorEvent{} },
+	"eventsDropped":          func() LiveEvent { return &EventsDroppedEvent{} },
+	"firstMessage":           func() LiveEvent { return &FirstMessageEvent{} },
+}
+
+var eventTypeNames = func() map[reflect.Type]string {
+	names := make(map[reflect.Type]string, len(eventTypes))
+	for name, newEvent := range eventTypes {
+		names[reflect.TypeOf(newEvent()).Elem()] = name
+	}
+	return names
+}()
+
+// EventTypeName returns the envelope type name of the event, such as "superChat".
+func EventTypeName(event LiveEvent) (string, bool) {
+	t := reflect.TypeOf(event)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name, ok := eventTypeNames[t]
+	return name, ok
+}
+
+// MarshalEvent encodes the event, a pointer or value, as an EventEnvelope.
+func MarshalEvent(event LiveEvent) ([]byte, error) {
+	name, ok := EventTypeName(event)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnknownEventType, event)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal %s event: %w", name, err)
+	}
+	return json.Marshal(EventEnvelope{
+		Version: EventEnvelopeVersion,
+		Type:    name,
+		ID:      event.ID(),
+		Data:    data,
+	})
+}
+
+// UnmarshalEvent decodes an EventEnvelope written by MarshalEvent. The event is returned as
+// a pointer, the same as the events received from Attach.
+func UnmarshalEvent(data []byte) (LiveEvent, error) {
+	var envelope EventEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("could not unmarshal event envelope: %w", err)
+	}
+	if envelope.Version < 1 || envelope.Version > EventEnvelopeVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedEnvelope, envelope.Version)
+	}
+	newEvent, ok := eventTypes[envelope.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, envelope.Type)
+	}
+	event := newEvent()
+	if len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, event); err != nil {
+			return nil, fmt.Errorf("could not unmarshal %s event: %w", envelope.Type, err)
+		}
+	}
+	return event, nil
+}
+
+type errorEventJSON struct {
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes Error as its message.
+func (e ErrorEvent) MarshalJSON() ([]byte, error) {
+	encoded := errorEventJSON{Timestamp: e.Timestamp}
+	if e.Error != nil {
+		encoded.Error = e.Error.Error()
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes Error as an error with the encoded message, the original error
+// type is not restored.
+func (e *ErrorEvent) UnmarshalJSON(data []byte) error {
+	var encoded errorEventJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	e.Timestamp = encoded.Timestamp
+	e.Error = nil
+	if encoded.Error != "" {
+		e.Error = errors.New(encoded.Error)
+	}
+	return nil
+}
+
+// UnmarshalJSON derives AmountMicros from Amount for events encoded before AmountMicros
+// was added.
+func (s *SuperChatEvent) UnmarshalJSON(data []byte) error {
+	type plain SuperChatEvent
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	if s.AmountMicros == 0 {
+		s.AmountMicros = amountMicros(s.Amount)
+	}
+	return nil
+}
+
+// UnmarshalJSON derives AmountMicros from Amount like SuperChatEvent.UnmarshalJSON.
+func (s *SuperStickerEvent) UnmarshalJSON(data []byte) error {
+	type plain SuperStickerEvent
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	if s.AmountMicros == 0 {
+		s.AmountMicros = amountMicros(s.Amount)
+	}
+	return nil
+}
+
+func amountMicros(amount float64) int64 {
+	return int64(math.Round(amount * 1_000_000))
+}
@@ -0,0 +1,105 @@
+package youtubelive
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleEvents() []LiveEvent {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+	author := AuthorDetails{ChannelId: "UCauthor", ChannelUrl: "http://youtube.com/channel/UCauthor", DisplayName: "author",
+		IsChatModerator: true, IsChatOwner: true, IsChatSponsor: true, IsVerified: true, ProfileImageUrl: "http://img"}
+	return []LiveEvent{
+		&ChatMessageEvent{MessageID: "m1", Message: "hello", DisplayName: "author", AuthorDetails: author, Timestamp: ts, NextPageToken: "p"},
+		&SuperChatEvent{Message: "thanks", Amount: 4.99, AmountMicros: 4_990_000, AmountDisplayString: "$4.99", Currency: "USD", Tier: 2, DisplayName: "author", AuthorDetails: author, Timestamp: ts, NextPageToken: "p"},
+		&SuperStickerEvent{StickerID: "s1", Amount: 2, AmountMicros: 2_000_000, AmountDisplayString: "€2.00", Currency: "EUR", Tier: 1, DisplayName: "author", AuthorDetails: author, Timestamp: ts, NextPageToken: "p"},
+		&MemberMilestoneEvent{DisplayName: "author", AuthorDetails: author, Level: "gold", Timestamp: ts, NextPageToken: "p", Months: 12},
+		&MembershipGiftEvent{DisplayName: "author", AuthorDetails: author, Total: 5, Tier: "gold", Timestamp: ts, NextPageToken: "p"},
+		&MembershipGiftReceivedEvent{DisplayText: "got a gift", Level: "gold", GifterID: "UCgifter", Timestamp: ts},
+		&NewMemberEvent{DisplayName: "author", AuthorDetails: author, Level: "gold", IsUpgrade: true, Timestamp: ts, NextPageToken: "p"},
+		&UserBannedEvent{BannedUserID: "UCbanned", BanType: "temporary", Duration: 5 * time.Minute, ModeratorID: "UCmod",
+			Timestamp: ts, NextPageToken: "p", BannedUserDisplayName: "banned", ModeratorDisplayName: "mod"},
+		&MessageDeletedEvent{DeletedMessageID: "m1", ModeratorID: "UCmod", ModeratorDisplayName: "mod", Timestamp: ts, NextPageToken: "p"},
+		&MessageRetractedEvent{RetractedMessageID: "m1", DisplayName: "author", AuthorDetails: author, Timestamp: ts, NextPageToken: "p"},
+		&MembersOnlyModeEvent{Enabled: true, Timestamp: ts, NextPageToken: "p"},
+		&TombstoneEvent{MessageID: "m1", Timestamp: ts, NextPageToken: "p"},
+		&PollEvent{PollID: "poll", Question: "which?", Options: []PollOption{{Text: "a", Tally: 1}, {Text: "b", Tally: 2}},
+			Status: "active", DisplayName: "author", AuthorDetails: author, Timestamp: ts, NextPageToken: "p"},
+		&UnknownEvent{MessageID: "m1", Type: "newThingEvent", DisplayMessage: "new thing", AuthorDetails: author, Timestamp: ts, NextPageToken: "p"},
+		&ChatEndedEvent{Timestamp: ts, NextPageToken: "p"},
+		&StreamEndEvent{},
+		&ErrorEvent{Timestamp: ts, Error: errors.New("something failed")},
+		&FirstMessageEvent{MessageID: "m", Message: "hi", DisplayName: "viewer", AuthorDetails: AuthorDetails{ChannelId: "UC"}, Timestamp: ts},
+		&EventsDroppedEvent{Dropped: 2, Total: 5, Policy: OverflowDropOldest, Timestamp: ts},
+	}
+}
+
+func TestMarshalEvent_RoundTrip(t *testing.T) {
+	samples := sampleEvents()
+	assert.Len(t, samples, len(eventTypes), "every event type needs a sample")
+	for _, event := range samples {
+		name, ok := EventTypeName(event)
+		if !assert.True(t, ok, "%T has no type name", event) {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			data, err := MarshalEvent(event)
+			if !assert.NoError(t, err) {
+				return
+			}
+			var envelope EventEnvelope
+			assert.NoError(t, json.Unmarshal(data, &envelope))
+			assert.Equal(t, EventEnvelopeVersion, envelope.Version)
+			assert.Equal(t, name, envelope.Type)
+			assert.Equal(t, event.ID(), envelope.ID)
+
+			decoded, err := UnmarshalEvent(data)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if e, ok := event.(*ErrorEvent); ok {
+				d := decoded.(*ErrorEvent)
+				assert.Equal(t, e.Timestamp, d.Timestamp)
+				assert.EqualError(t, d.Error, e.Error.Error())
+				return
+			}
+			assert.Equal(t, event, decoded)
+		})
+	}
+}
+
+func TestMarshalEvent_Values(t *testing.T) {
+	data, err := MarshalEvent(SuperChatEvent{Message: "value", Amount: 1, AmountMicros: 1_000_000})
+	assert.NoError(t, err)
+	decoded, err := UnmarshalEvent(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &SuperChatEvent{Message: "value", Amount: 1, AmountMicros: 1_000_000}, decoded)
+}
+
+func TestUnmarshalEvent_Errors(t *testing.T) {
+	_, err := UnmarshalEvent([]byte(`{"version":1,"type":"somethingNew","data":{}}`))
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+	_, err = UnmarshalEvent([]byte(`{"version":2,"type":"chatMessage","data":{}}`))
+	assert.ErrorIs(t, err, ErrUnsupportedEnvelope)
+	_, err = UnmarshalEvent([]byte(`{"version":1,"type":"chatMessage","data":{"message":1}}`))
+	assert.Error(t, err)
+
+	type otherEvent struct{ LiveEvent }
+	_, err = MarshalEvent(otherEvent{})
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+}
+
+func TestUnmarshalEvent_AmountMicrosFallback(t *testing.T) {
+	event, err := UnmarshalEvent([]byte(`{"version":1,"type":"superChat","data":{"amount":4.99,"currency":"USD"}}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(4_990_000), event.(*SuperChatEvent).AmountMicros)
+	}
+	event, err = UnmarshalEvent([]byte(`{"version":1,"type":"superSticker","data":{"amount":0.1,"amountMicros":100000}}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(100_000), event.(*SuperStickerEvent).AmountMicros)
+	}
+}
@@ -24,11 +24,14 @@ type AuthorDetails struct {
 }
 
 type ChatMessageEvent struct {
-	Message       string
-	DisplayName   string
-	AuthorDetails AuthorDetails
-	Timestamp     time.Time
-	NextPageToken string
+	// MessageID can be used with BotDeleteMessage and is referenced by
+	// MessageDeletedEvent and MessageRetractedEvent.
+	MessageID     string        `json:"messageId"`
+	Message       string        `json:"message"`
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Timestamp     time.Time     `json:"timestamp"`
+	NextPageToken string        `json:"nextPageToken"`
 }
 
 func (c ChatMessageEvent) ID() string {
@@ -36,13 +39,23 @@ func (c ChatMessageEvent) ID() string {
 }
 
 type SuperChatEvent struct {
-	Message       string
-	Amount        float64
-	Currency      string
-	DisplayName   string
-	AuthorDetails AuthorDetails
-	Timestamp     time.Time
-	NextPageToken string
+	Message string `json:"message"`
+	// Amount is AmountMicros in currency units, use AmountMicros to add up amounts.
+	Amount float64 `json:"amount"`
+	// AmountMicros is the exact amount in micros of the currency, 1,000,000 micros is one unit.
+	AmountMicros int64 `json:"amountMicros"`
+	// AmountDisplayString is the amount with the currency symbol as shown in the chat, such
+	// as "$1.00".
+	AmountDisplayString string `json:"amountDisplayString,omitempty"`
+	// Currency is the ISO 4217 currency code.
+	Currency string `json:"currency"`
+	// Tier is the tier of the Super Chat based on the amount, it determines its color and
+	// how long it stays pinned.
+	Tier          int           `json:"tier,omitempty"`
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Timestamp     time.Time     `json:"timestamp"`
+	NextPageToken string        `json:"nextPageToken"`
 }
 
 func (s SuperChatEvent) ID() string {
@@ -50,13 +63,21 @@ func (s SuperChatEvent) ID() string {
 }
 
 type SuperStickerEvent struct {
-	StickerID     string
-	Amount        float64
-	Currency      string
-	DisplayName   string
-	AuthorDetails AuthorDetails
-	Timestamp     time.Time
-	NextPageToken string
+	StickerID string `json:"stickerId"`
+	// Amount is AmountMicros in currency units, use AmountMicros to add up amounts.
+	Amount float64 `json:"amount"`
+	// AmountMicros is the exact amount in micros of the currency, 1,000,000 micros is one unit.
+	AmountMicros int64 `json:"amountMicros"`
+	// AmountDisplayString is the amount with the currency symbol as shown in the chat.
+	AmountDisplayString string `json:"amountDisplayString,omitempty"`
+	// Currency is the ISO 4217 currency code.
+	Currency string `json:"currency"`
+	// Tier is the tier of the Super Sticker based on the amount.
+	Tier          int           `json:"tier,omitempty"`
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Timestamp     time.Time     `json:"timestamp"`
+	NextPageToken string        `json:"nextPageToken"`
 }
 
 func (s SuperStickerEvent) ID() string {
@@ -64,12 +85,12 @@ func (s SuperStickerEvent) ID() string {
 }
 
 type MemberMilestoneEvent struct {
-	DisplayName   string
-	AuthorDetails AuthorDetails
-	Level         string // "new", "returning", "creator"
-	Timestamp     time.Time
-	NextPageToken string
-	Months        int
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Level         string        `json:"level"` // "new", "returning", "creator"
+	Timestamp     time.Time     `json:"timestamp"`
+	NextPageToken string        `json:"nextPageToken"`
+	Months        int           `json:"months"`
 }
 
 func (s MemberMilestoneEvent) ID() string {
@@ -77,12 +98,12 @@ func (s MemberMilestoneEvent) ID() string {
 }
 
 type MembershipGiftEvent struct {
-	DisplayName   string
-	AuthorDetails AuthorDetails
-	Total         int
-	Tier          string
-	Timestamp     time.Time
-	NextPageToken string
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Total         int           `json:"total"`
+	Tier          string        `json:"tier"`
+	Timestamp     time.Time     `json:"timestamp"`
+	NextPageToken string        `json:"nextPageToken"`
 }
 
 func (s MembershipGiftEvent) ID() string {
@@ -90,8 +111,8 @@ func (s MembershipGiftEvent) ID() string {
 }
 
 type ChatEndedEvent struct {
-	Timestamp     time.Time
-	NextPageToken string
+	Timestamp     time.Time `json:"timestamp"`
+	NextPageToken string    `json:"nextPageToken"`
 }
 
 func (s ChatEndedEvent) ID() string {
@@ -105,22 +126,32 @@ func (s StreamEndEvent) ID() string {
 }
 
 type BotChatMessage struct {
-	Message string
+	Message string `json:"message"`
+	// ReplyTo is the MessageID of the chat message being replied to. It is not shown in the
+	// chat, it links the trace of sending the reply to the trace of the message.
+	ReplyTo string `json:"replyTo,omitempty"`
 }
 
 type BotDeleteMessage struct {
-	MessageID string
+	MessageID string `json:"messageId"`
+}
+
+// BotBanUser bans the channel from the live chat, for Duration or permanently when it
+// is 0.
+type BotBanUser struct {
+	ChannelID string        `json:"channelId"`
+	Duration  time.Duration `json:"duration,omitempty"`
 }
 
 type UserBannedEvent struct {
-	BannedUserID          string
-	BanType               string // "permanent" or "temporary"
-	Duration              time.Duration
-	ModeratorID           string
-	Timestamp             time.Time
-	NextPageToken         string
-	BannedUserDisplayName string
-	ModeratorDisplayName  string
+	BannedUserID          string        `json:"bannedUserId"`
+	BanType               string        `json:"banType"` // "permanent" or "temporary"
+	Duration              time.Duration `json:"duration"`
+	ModeratorID           string        `json:"moderatorId"`
+	Timestamp             time.Time     `json:"timestamp"`
+	NextPageToken         string        `json:"nextPageToken"`
+	BannedUserDisplayName string        `json:"bannedUserDisplayName"`
+	ModeratorDisplayName  string        `json:"moderatorDisplayName"`
 }
 
 func (u UserBannedEvent) ID() string {
@@ -128,19 +159,147 @@ func (u UserBannedEvent) ID() string {
 }
 
 type MembershipGiftReceivedEvent struct {
-	DisplayText string
-	Level       string
-	GifterID    string
-	Timestamp   time.Time
+	DisplayText string    `json:"displayText"`
+	Level       string    `json:"level"`
+	GifterID    string    `json:"gifterId"`
+	Timestamp   time.Time `json:"timestamp"`
 }
 
 func (m MembershipGiftReceivedEvent) ID() string {
 	return fmt.Sprintf("giftreceived-%s-%d", m.GifterID, m.Timestamp.Unix())
 }
 
+// NewMemberEvent is sent when a user becomes a member of the channel or upgrades their
+// membership level.
+type NewMemberEvent struct {
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Level         string        `json:"level"`
+	IsUpgrade     bool          `json:"isUpgrade"`
+	Timestamp     time.Time     `json:"timestamp"`
+	NextPageToken string        `json:"nextPageToken"`
+}
+
+func (n NewMemberEvent) ID() string {
+	return fmt.Sprintf("member-%s-%d", n.DisplayName, n.Timestamp.UnixNano())
+}
+
+// MessageDeletedEvent is sent when a moderator deletes a chat message.
+type MessageDeletedEvent struct {
+	DeletedMessageID     string    `json:"deletedMessageId"`
+	ModeratorID          string    `json:"moderatorId"`
+	ModeratorDisplayName string    `json:"moderatorDisplayName"`
+	Timestamp            time.Time `json:"timestamp"`
+	NextPageToken        string    `json:"nextPageToken"`
+}
+
+func (m MessageDeletedEvent) ID() string {
+	return fmt.Sprintf("deleted-%s-%d", m.DeletedMessageID, m.Timestamp.UnixNano())
+}
+
+// MessageRetractedEvent is sent when the author retracts their own chat message.
+type MessageRetractedEvent struct {
+	RetractedMessageID string        `json:"retractedMessageId"`
+	DisplayName        string        `json:"displayName"`
+	AuthorDetails      AuthorDetails `json:"authorDetails"`
+	Timestamp          time.Time     `json:"timestamp"`
+	NextPageToken      string        `json:"nextPageToken"`
+}
+
+func (m MessageRetractedEvent) ID() string {
+	return fmt.Sprintf("retracted-%s-%d", m.RetractedMessageID, m.Timestamp.UnixNano())
+}
+
+// MembersOnlyModeEvent is sent when members-only chat is turned on or off.
+type MembersOnlyModeEvent struct {
+	Enabled       bool      `json:"enabled"`
+	Timestamp     time.Time `json:"timestamp"`
+	NextPageToken string    `json:"nextPageToken"`
+}
+
+func (m MembersOnlyModeEvent) ID() string {
+	return fmt.Sprintf("membersonly-%t-%d", m.Enabled, m.Timestamp.UnixNano())
+}
+
+// TombstoneEvent takes the place of a message that is no longer available.
+type TombstoneEvent struct {
+	MessageID     string    `json:"messageId"`
+	Timestamp     time.Time `json:"timestamp"`
+	NextPageToken string    `json:"nextPageToken"`
+}
+
+func (t TombstoneEvent) ID() string {
+	return fmt.Sprintf("tombstone-%s-%d", t.MessageID, t.Timestamp.UnixNano())
+}
+
+type PollOption struct {
+	Text  string `json:"text"`
+	Tally int    `json:"tally"`
+}
+
+// PollEvent is sent when a poll is started, updated or closed.
+type PollEvent struct {
+	PollID        string        `json:"pollId"`
+	Question      string        `json:"question"`
+	Options       []PollOption  `json:"options"`
+	Status        string        `json:"status"` // "active", "closed" or "unknown"
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Timestamp     time.Time     `json:"timestamp"`
+	NextPageToken string        `json:"nextPageToken"`
+}
+
+func (p PollEvent) ID() string {
+	return fmt.Sprintf("poll-%s-%s-%d", p.PollID, p.Status, p.Timestamp.UnixNano())
+}
+
+// UnknownEvent is sent for chat messages of a type this package does not support yet so
+// no chat activity is lost.
+type UnknownEvent struct {
+	MessageID      string        `json:"messageId"`
+	Type           string        `json:"type"`
+	DisplayMessage string        `json:"displayMessage"`
+	AuthorDetails  AuthorDetails `json:"authorDetails"`
+	Timestamp      time.Time     `json:"timestamp"`
+	NextPageToken  string        `json:"nextPageToken"`
+}
+
+func (u UnknownEvent) ID() string {
+	return fmt.Sprintf("unknown-%s-%s-%d", u.Type, u.MessageID, u.Timestamp.UnixNano())
+}
+
+// FirstMessageEvent is delivered by the Viewers middleware right before the first chat
+// message of a user in the session.
+type FirstMessageEvent struct {
+	MessageID     string        `json:"messageId"`
+	Message       string        `json:"message"`
+	DisplayName   string        `json:"displayName"`
+	AuthorDetails AuthorDetails `json:"authorDetails"`
+	Timestamp     time.Time     `json:"timestamp"`
+}
+
+func (f FirstMessageEvent) ID() string {
+	return fmt.Sprintf("first-%s-%d", f.AuthorDetails.ChannelId, f.Timestamp.UnixNano())
+}
+
+// EventsDroppedEvent is delivered before the next event when events of the attached chat
+// were dropped because the consumer fell behind, see EventOverflow.
+type EventsDroppedEvent struct {
+	// Dropped is the number of events dropped since the previous EventsDroppedEvent.
+	Dropped int `json:"dropped"`
+	// Total is the number of events dropped since Attach.
+	Total     int            `json:"total"`
+	Policy    OverflowPolicy `json:"policy"`
+	Timestamp time.Time      `json:"timestamp"` // of the last dropped event
+}
+
+func (e EventsDroppedEvent) ID() string {
+	return fmt.Sprintf("dropped-%d-%d", e.Total, e.Timestamp.UnixNano())
+}
+
 type ErrorEvent struct {
-	Timestamp time.Time
-	Error     error
+	Timestamp time.Time `json:"timestamp"`
+	Error     error     `json:"-"` // encoded as its message, see MarshalJSON
 }
 
 func (e ErrorEvent) ID() string {
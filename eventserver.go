@@ -0,0 +1,225 @@
+package youtubelive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+type EventServerOption func(s *EventServer)
+
+// EventServerBuffer sets how many events are buffered per subscriber, events for a
+// subscriber with a full buffer are dropped. The default is 100.
+func EventServerBuffer(size int) EventServerOption {
+	return func(s *EventServer) {
+		s.buffer = max(size, 1)
+	}
+}
+
+// EventServerLogger sets the logger for dropped events, the default is slog.Default().
+func EventServerLogger(log *slog.Logger) EventServerOption {
+	return func(s *EventServer) {
+		s.log = log
+	}
+}
+
+// EventServerOrigins sets the origins, other than the origin of the server itself, that
+// web pages may open a WebSocket from, such as "localhost:3000" for an overlay served on
+// another port. The patterns are host patterns as matched by path.Match, such as
+// "*.example.com". By default only same-origin pages and clients that send no Origin,
+// such as non-browser clients, can connect.
+func EventServerOrigins(patterns ...string) EventServerOption {
+	return func(s *EventServer) {
+		s.originPatterns = append(s.originPatterns, patterns...)
+	}
+}
+
+// EventServer serves the events of an attached chat to local subscribers over
+// Server-Sent Events and WebSocket. Every event is an EventEnvelope, see MarshalEvent.
+type EventServer struct {
+	buffer         int
+	log            *slog.Logger
+	originPatterns []string
+
+	mu          sync.Mutex
+	subscribers map[chan []byte]struct{}
+	done        bool
+}
+
+func NewEventServer(options ...EventServerOption) *EventServer {
+	s := &EventServer{
+		buffer:      100,
+		log:         slog.Default(),
+		subscribers: make(map[chan []byte]struct{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Run sends the events to the subscribers until the events channel is closed or ctx is
+// done, then the subscriptions are closed. Run can only be called once.
+func (s *EventServer) Run(ctx context.Context, events <-chan LiveEvent) error {
+	defer s.closeSubscribers()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			data, err := MarshalEvent(event)
+			if err != nil {
+				s.log.Warn("could not encode event for subscribers", "error", err)
+				continue
+			}
+			s.publish(data)
+		}
+	}
+}
+
+func (s *EventServer) publish(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		select {
+		case sub <- data:
+		default:
+			s.log.Debug("subscriber is too slow, event dropped")
+		}
+	}
+}
+
+func (s *EventServer) subscribe() chan []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := make(chan []byte, s.buffer)
+	if s.done {
+		close(sub)
+		return sub
+	}
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (s *EventServer) unsubscribe(sub chan []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub)
+	}
+}
+
+func (s *EventServer) closeSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = true
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub)
+	}
+}
+
+// ServeHTTP serves a WebSocket, with a text message per event, when the request is a
+// WebSocket upgrade and Server-Sent Events otherwise.
+func (s *EventServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		s.serveWebSocket(w, r)
+		return
+	}
+	s.serveSSE(w, r)
+}
+
+func (s *EventServer) serveSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sub := s.subscribe()
+	defer s.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data, ok := <-sub:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *EventServer) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Unlike Server-Sent Events, any page could read the socket, so cross-origin pages
+	// are only accepted from EventServerOrigins.
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: s.originPatterns})
+	if err != nil {
+		// Accept has responded with the error.
+		return
+	}
+	defer func() {
+		_ = conn.CloseNow()
+	}()
+	sub := s.subscribe()
+	defer s.unsubscribe(sub)
+
+	// Subscribers do not send anything, ctx is done when they disconnect.
+	ctx := conn.CloseRead(r.Context())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data, ok := <-sub:
+			if !ok {
+				_ = conn.Close(websocket.StatusNormalClosure, "events ended")
+				return
+			}
+			if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ListenAndServe serves the events on addr until ctx is done.
+func (s *EventServer) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Ends the Server-Sent Events streams, which never go idle, on shutdown.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
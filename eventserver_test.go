@@ -0,0 +1,97 @@
+package youtubelive
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventServer_Subscribers(t *testing.T) {
+	server := NewEventServer()
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ws, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.CloseNow()
+	assert.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.subscribers) == 2
+	}, time.Second, time.Millisecond)
+
+	events := make(chan LiveEvent, 1)
+	go func() {
+		_ = server.Run(ctx, events)
+	}()
+	events <- chatFrom(AuthorDetails{}, "hello")
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	assert.NoError(t, err)
+	event, err := UnmarshalEvent([]byte(strings.TrimPrefix(line, "data: ")))
+	assert.NoError(t, err)
+	assert.Equal(t, chatFrom(AuthorDetails{}, "hello"), event)
+
+	_, msg, err := ws.Read(ctx)
+	assert.NoError(t, err)
+	event, err = UnmarshalEvent(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, chatFrom(AuthorDetails{}, "hello"), event)
+
+	close(events)
+	_, _, err = ws.Read(ctx)
+	assert.Equal(t, websocket.StatusNormalClosure, websocket.CloseStatus(err), "closed when the events end")
+}
+
+func TestEventServer_WebSocketOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []EventServerOption
+		origin  string
+		allowed bool
+	}{
+		{"no origin", nil, "", true},
+		{"foreign origin", nil, "http://evil.example", false},
+		{"allowed origin", []EventServerOption{EventServerOrigins("*.example")}, "http://overlay.example", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(NewEventServer(tt.options...))
+			defer srv.Close()
+
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			ws, resp, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), &websocket.DialOptions{HTTPHeader: header})
+			if !tt.allowed {
+				assert.Error(t, err)
+				if assert.NotNil(t, resp) {
+					assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				_ = ws.CloseNow()
+			}
+		})
+	}
+}
@@ -12,6 +12,8 @@ var (
 	clientSecret     = ""
 	refreshToken     = ""
 	additionalScopes = []string{}
+	// apiKey is enough to check the live status, no user consent is required when set.
+	apiKey = ""
 )
 
 const (
@@ -26,8 +28,17 @@ func main() {
 		if err != nil {
 			panic(err)
 		}
+		env, err := yt.ReadFromDotFile()
+		if err != nil {
+			panic(err)
+		}
+		apiKey = env["api_key"]
+	}
+	options := []yt.Option{yt.RefreshToken(refreshToken)}
+	if apiKey != "" {
+		options = append(options, yt.APIKey(apiKey))
 	}
-	ytLive, err := yt.NewYouTubeLive(clientID, clientSecret, yt.RefreshToken(refreshToken))
+	ytLive, err := yt.NewYouTubeLive(clientID, clientSecret, options...)
 	if err != nil {
 		panic(err)
 	}
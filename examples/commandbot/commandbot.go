@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	yt "github.com/steampoweredtaco/youtubelive"
+	"time"
+)
+
+var (
+	channel          = "@christinafixates"
+	clientID         = ""
+	clientSecret     = ""
+	refreshToken     = ""
+	additionalScopes = []string{}
+)
+
+const (
+	// Can set this to false if you want to hardcode the values above (not recommended).  See LoadOauthCredentialsFromDotFile.
+	loadFromDotEnv = true
+)
+
+func main() {
+	if loadFromDotEnv {
+		var err error
+		clientID, clientSecret, refreshToken, additionalScopes, err = yt.LoadOauthCredentialsFromDotFile()
+		if err != nil {
+			panic(err)
+		}
+	}
+	ytLive, err := yt.NewYouTubeLive(clientID, clientSecret, yt.RefreshToken(refreshToken), yt.AutoAuthenticate())
+	if err != nil {
+		panic(err)
+	}
+	broadcastID, err := ytLive.CurrentBroadcastIDFromChannelHandle(channel)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 1*time.Hour, errors.New("demo over"))
+	defer cancel()
+	events, commands, err := ytLive.Attach(ctx, broadcastID)
+	if err != nil {
+		panic(err)
+	}
+
+	router := yt.NewRouter(yt.NotCommand(func(event yt.LiveEvent) {
+		if e, ok := event.(*yt.ChatMessageEvent); ok {
+			fmt.Printf("[%s] %s: %s\n", e.Timestamp.Local().Format(time.Stamp), e.DisplayName, e.Message)
+		}
+	}))
+	err = router.Handle(yt.Command{
+		Name:         "so",
+		Aliases:      []string{"shoutout"},
+		UserCooldown: time.Minute,
+		Handler: func(c *yt.CommandContext) error {
+			if len(c.Args) == 0 {
+				return c.Reply("usage: !so <name>")
+			}
+			return c.Reply(fmt.Sprintf("Go check out %s!", c.Args[0]))
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	err = router.Handle(yt.Command{
+		Name:       "clear",
+		Permission: yt.PermissionModerator,
+		Handler: func(c *yt.CommandContext) error {
+			return c.Delete()
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if err := router.Run(ctx, events, commands); err != nil {
+		fmt.Println("router stopped:", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	yt "github.com/steampoweredtaco/youtubelive"
+	"time"
+)
+
+var (
+	channel          = "@christinafixates"
+	clientID         = ""
+	clientSecret     = ""
+	refreshToken     = ""
+	additionalScopes = []string{}
+)
+
+const (
+	// Can set this to false if you want to hardcode the values above (not recommended).  See LoadOauthCredentialsFromDotFile.
+	loadFromDotEnv = true
+)
+
+func main() {
+	if loadFromDotEnv {
+		var err error
+		clientID, clientSecret, refreshToken, additionalScopes, err = yt.LoadOauthCredentialsFromDotFile()
+		if err != nil {
+			panic(err)
+		}
+	}
+	ytLive, err := yt.NewYouTubeLive(clientID, clientSecret, yt.RefreshToken(refreshToken))
+	if err != nil {
+		panic(err)
+	}
+	broadcastID, err := ytLive.CurrentBroadcastIDFromChannelHandle(channel)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 1*time.Hour, errors.New("demo over"))
+	defer cancel()
+	events, _, err := ytLive.Attach(ctx, broadcastID)
+	if err != nil {
+		panic(err)
+	}
+
+	d := yt.NewDispatcher(yt.DispatchConcurrency(4))
+	d.OnChatMessage(func(e *yt.ChatMessageEvent) {
+		fmt.Printf("[%s] %s: %s\n", e.Timestamp.Local().Format(time.Stamp), e.DisplayName, e.Message)
+	})
+	d.OnSuperChat(func(e *yt.SuperChatEvent) {
+		fmt.Printf("[%s] 💎 Super Chat from %s: %s (%.2f %s)\n",
+			e.Timestamp.Local().Format(time.Stamp), e.DisplayName, e.Message, e.Amount, e.Currency)
+	})
+	d.OnBan(func(e *yt.UserBannedEvent) {
+		fmt.Printf("[%s] 🔨 Moderator %s banned %s (%s)\n",
+			e.Timestamp.Local().Format(time.Stamp), e.ModeratorDisplayName, e.BannedUserDisplayName, e.BanType)
+	})
+	d.OnChatEnded(func(e *yt.ChatEndedEvent) {
+		fmt.Printf("[%s] ⏹️ Live chat has ended\n", e.Timestamp.Local().Format(time.Stamp))
+	})
+	d.OnError(func(e *yt.ErrorEvent) {
+		fmt.Printf("[%s] ⚠️ %s\n", e.Timestamp.Local().Format(time.Stamp), e.Error)
+	})
+	d.OnUnhandled(func(e yt.LiveEvent) {
+		fmt.Printf("⚠️ Unhandled event type: %T\n", e)
+	})
+
+	if err := d.Run(ctx, events); err != nil {
+		fmt.Println(err)
+	}
+}
@@ -112,6 +112,45 @@ func main() {
 			}
 			fmt.Println(banMsg)
 
+		case *yt.NewMemberEvent:
+			fmt.Printf("[%s] 🎉 New %s member: %s\n",
+				e.Timestamp.Local().Format(time.Stamp),
+				e.Level,
+				e.DisplayName)
+
+		case *yt.MessageDeletedEvent:
+			fmt.Printf("[%s] 🗑️ Moderator %s deleted message %s\n",
+				e.Timestamp.Local().Format(time.Stamp),
+				e.ModeratorDisplayName,
+				e.DeletedMessageID)
+
+		case *yt.MessageRetractedEvent:
+			fmt.Printf("[%s] ↩️ %s retracted message %s\n",
+				e.Timestamp.Local().Format(time.Stamp),
+				e.DisplayName,
+				e.RetractedMessageID)
+
+		case *yt.MembersOnlyModeEvent:
+			fmt.Printf("[%s] 🔒 Members-only mode enabled: %v\n",
+				e.Timestamp.Local().Format(time.Stamp),
+				e.Enabled)
+
+		case *yt.PollEvent:
+			fmt.Printf("[%s] 📊 Poll (%s) %s: %v\n",
+				e.Timestamp.Local().Format(time.Stamp),
+				e.Status,
+				e.Question,
+				e.Options)
+
+		case *yt.TombstoneEvent:
+			// Message placeholder that is no longer available, nothing to show.
+
+		case *yt.UnknownEvent:
+			fmt.Printf("[%s] ❔ %s: %s\n",
+				e.Timestamp.Local().Format(time.Stamp),
+				e.Type,
+				e.DisplayMessage)
+
 		case *yt.ChatEndedEvent:
 			fmt.Printf("[%s] ⏹️ Live chat has ended\n",
 				e.Timestamp.Local().Format(time.Stamp))
@@ -0,0 +1,502 @@
+package youtubelive
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// fakeYouTube is an in memory stand in for the YouTube Data API and the Google OAuth2
+// token endpoint. It is used as the HttpTransport so requests never leave the test.
+type fakeYouTube struct {
+	mu  sync.Mutex
+	mux *http.ServeMux
+
+	videos       map[string]*youtube.Video
+	handles      map[string]string
+	uploads      map[string][]string
+	pending      []*youtube.LiveChatMessage
+	chatPending  map[string][]*youtube.LiveChatMessage
+	pollInterval int64
+	page         int
+
+	// authorizations are the bearer tokens used per request path.
+	authorizations map[string][]string
+	// calls are the number of requests per request path.
+	calls map[string]int
+	// bytes are the response body sizes per request path after applying the fields
+	// parameter, fullBytes are the sizes without it.
+	bytes     map[string]int
+	fullBytes map[string]int
+	sent      []string
+	bans      []*youtube.LiveChatBanSnippet
+	// exhausted are the access tokens and api keys that respond with quotaExceeded.
+	exhausted map[string]bool
+	// failing are the request paths that respond with the error reason.
+	failing map[string]string
+	// tokenGate, when set, holds up every token request until it is closed.
+	tokenGate chan struct{}
+
+	// listDelay delays every liveChatMessages.list, inFlight and maxInFlight count the
+	// concurrent ones.
+	listDelay   time.Duration
+	inFlight    int
+	maxInFlight int
+}
+
+func newFakeYouTube() *fakeYouTube {
+	f := &fakeYouTube{
+		mux:            http.NewServeMux(),
+		videos:         make(map[string]*youtube.Video),
+		handles:        make(map[string]string),
+		exhausted:      make(map[string]bool),
+		failing:        make(map[string]string),
+		chatPending:    make(map[string][]*youtube.LiveChatMessage),
+		pollInterval:   10,
+		authorizations: make(map[string][]string),
+		calls:          make(map[string]int),
+		bytes:          make(map[string]int),
+		fullBytes:      make(map[string]int),
+		uploads:        make(map[string][]string),
+	}
+	f.mux.HandleFunc("POST /token", f.token)
+	f.mux.HandleFunc("GET /youtube/v3/channels", f.listChannels)
+	f.mux.HandleFunc("GET /youtube/v3/videos", f.listVideos)
+	f.mux.HandleFunc("GET /youtube/v3/playlistItems", f.listPlaylistItems)
+	f.mux.HandleFunc("GET /youtube/v3/search", f.search)
+	f.mux.HandleFunc("GET /youtube/v3/liveChat/messages", f.listMessages)
+	f.mux.HandleFunc("POST /youtube/v3/liveChat/messages", f.insertMessage)
+	f.mux.HandleFunc("POST /youtube/v3/liveChat/bans", f.insertBan)
+	return f
+}
+
+func (f *fakeYouTube) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+	credential := r.URL.Query().Get("key")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		credential = strings.TrimPrefix(auth, "Bearer ")
+	}
+	f.mu.Lock()
+	f.calls[r.URL.Path]++
+	if credential != "" {
+		f.authorizations[r.URL.Path] = append(f.authorizations[r.URL.Path], credential)
+	}
+	exhausted := f.exhausted[credential]
+	failing := f.failing[r.URL.Path]
+	f.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	switch {
+	case exhausted:
+		writeAPIError(rec, http.StatusForbidden, "quotaExceeded")
+	case failing != "":
+		writeAPIError(rec, http.StatusForbidden, failing)
+	default:
+		f.mux.ServeHTTP(rec, r)
+	}
+	full := rec.Body.Len()
+	if fields := r.URL.Query().Get("fields"); fields != "" && rec.Code == http.StatusOK {
+		var body any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			return nil, err
+		}
+		rec.Body.Reset()
+		_ = json.NewEncoder(rec.Body).Encode(parseFields(fields).apply(body))
+	}
+	f.mu.Lock()
+	f.bytes[r.URL.Path] += rec.Body.Len()
+	f.fullBytes[r.URL.Path] += full
+	f.mu.Unlock()
+
+	resp := rec.Result()
+	resp.Header.Set("Content-Length", strconv.Itoa(rec.Body.Len()))
+	resp.Request = r
+	return resp, nil
+}
+
+// fieldMask is a parsed partial response fields parameter, a nil mask selects the whole
+// field.
+type fieldMask map[string]fieldMask
+
+func parseFields(fields string) fieldMask {
+	mask, _ := parseFieldList(fields, 0)
+	return mask
+}
+
+func parseFieldList(fields string, i int) (fieldMask, int) {
+	mask := fieldMask{}
+	for i < len(fields) {
+		var (
+			name string
+			sub  fieldMask
+		)
+		name, sub, i = parseField(fields, i)
+		mask.merge(name, sub)
+		if i >= len(fields) || fields[i] != ',' {
+			break
+		}
+		i++
+	}
+	return mask, i
+}
+
+func parseField(fields string, i int) (string, fieldMask, int) {
+	j := i
+	for j < len(fields) && !strings.ContainsRune(",/()", rune(fields[j])) {
+		j++
+	}
+	name := fields[i:j]
+	if j >= len(fields) {
+		return name, nil, j
+	}
+	switch fields[j] {
+	case '/':
+		subName, subMask, end := parseField(fields, j+1)
+		return name, fieldMask{subName: subMask}, end
+	case '(':
+		sub, end := parseFieldList(fields, j+1)
+		return name, sub, end + 1 // skip the )
+	}
+	return name, nil, j
+}
+
+func (m fieldMask) merge(name string, sub fieldMask) {
+	existing, ok := m[name]
+	switch {
+	case !ok:
+		m[name] = sub
+	case existing == nil || sub == nil:
+		m[name] = nil
+	default:
+		for k, v := range sub {
+			existing.merge(k, v)
+		}
+	}
+}
+
+func (m fieldMask) apply(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(m))
+		for name, sub := range m {
+			if field, ok := v[name]; ok {
+				if sub != nil {
+					field = sub.apply(field)
+				}
+				out[name] = field
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = m.apply(item)
+		}
+		return out
+	}
+	return v
+}
+
+// addLiveVideo registers a live video with an active live chat.
+func (f *fakeYouTube) addLiveVideo(videoID, liveChatID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.videos[videoID] = &youtube.Video{
+		Id:      videoID,
+		Snippet: &youtube.VideoSnippet{ChannelId: "UC-" + videoID, LiveBroadcastContent: "live"},
+		LiveStreamingDetails: &youtube.VideoLiveStreamingDetails{
+			ActiveLiveChatId: liveChatID,
+			ActualStartTime:  time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+// addUpload adds a video that is not live to the uploads of the channel, the most recent
+// upload is added last.
+func (f *fakeYouTube) addUpload(channelID, videoID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads[channelID] = append(f.uploads[channelID], videoID)
+	if _, ok := f.videos[videoID]; !ok {
+		f.videos[videoID] = &youtube.Video{
+			Id:      videoID,
+			Snippet: &youtube.VideoSnippet{ChannelId: channelID, LiveBroadcastContent: "none"},
+			LiveStreamingDetails: &youtube.VideoLiveStreamingDetails{
+				ActualStartTime: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+				ActualEndTime:   time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+			},
+		}
+	}
+}
+
+func (f *fakeYouTube) responseBytes(path string) (filtered, full int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bytes[path], f.fullBytes[path]
+}
+
+func (f *fakeYouTube) callCount(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[path]
+}
+
+// queue adds messages returned by the next live chat poll.
+func (f *fakeYouTube) queue(msgs ...*youtube.LiveChatMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = append(f.pending, msgs...)
+}
+
+// queueFor adds messages returned by the next poll of one live chat.
+func (f *fakeYouTube) queueFor(liveChatID string, msgs ...*youtube.LiveChatMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chatPending[liveChatID] = append(f.chatPending[liveChatID], msgs...)
+}
+
+func (f *fakeYouTube) maxListsInFlight() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.maxInFlight
+}
+
+// addChannel registers a channel handle, including the @ prefix.
+func (f *fakeYouTube) addChannel(handle, channelID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handles[handle] = channelID
+}
+
+// exhaust makes requests using the access token or api key fail with quotaExceeded.
+// fail makes every request to the path respond with the error reason.
+func (f *fakeYouTube) fail(path, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing[path] = reason
+}
+
+func (f *fakeYouTube) exhaust(credential string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.exhausted[credential] = true
+}
+
+func (f *fakeYouTube) authorizationsFor(path string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.authorizations[path]...)
+}
+
+func (f *fakeYouTube) lastAuthorization(path string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	auths := f.authorizations[path]
+	if len(auths) == 0 {
+		return ""
+	}
+	return auths[len(auths)-1]
+}
+
+func (f *fakeYouTube) sentMessages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...)
+}
+
+func (f *fakeYouTube) token(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	gate := f.tokenGate
+	f.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+	refreshToken := r.FormValue("refresh_token")
+	if refreshToken == "" || refreshToken == "revoked" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"error":"invalid_grant"}`)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"access_token":  "access-" + refreshToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+func (f *fakeYouTube) listChannels(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &youtube.ChannelListResponse{}
+	if id, ok := f.handles[r.FormValue("forHandle")]; ok {
+		resp.Items = append(resp.Items, &youtube.Channel{Id: id})
+	}
+	if r.FormValue("mine") == "true" {
+		resp.Items = append(resp.Items, &youtube.Channel{Id: testBotChannelID, Snippet: &youtube.ChannelSnippet{Title: "bot"}})
+	}
+	if id := r.FormValue("id"); id != "" {
+		resp.Items = append(resp.Items, &youtube.Channel{
+			Id:             id,
+			ContentDetails: &youtube.ChannelContentDetails{RelatedPlaylists: &youtube.ChannelContentDetailsRelatedPlaylists{Uploads: "UU-" + id}},
+		})
+	}
+	writeJSON(w, resp)
+}
+
+// search finds the live videos of the channelId, the other search parameters are ignored.
+func (f *fakeYouTube) search(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &youtube.SearchListResponse{}
+	for _, v := range f.videos {
+		if v.Snippet != nil && v.Snippet.ChannelId == r.FormValue("channelId") && v.Snippet.LiveBroadcastContent == "live" {
+			resp.Items = append(resp.Items, &youtube.SearchResult{Id: &youtube.ResourceId{Kind: "youtube#video", VideoId: v.Id}})
+		}
+	}
+	writeJSON(w, resp)
+}
+
+func (f *fakeYouTube) listPlaylistItems(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &youtube.PlaylistItemListResponse{Kind: "youtube#playlistItemListResponse", Etag: "etag"}
+	uploads := f.uploads[strings.TrimPrefix(r.FormValue("playlistId"), "UU-")]
+	for i := len(uploads) - 1; i >= 0; i-- {
+		resp.Items = append(resp.Items, &youtube.PlaylistItem{
+			Kind:           "youtube#playlistItem",
+			Etag:           "etag",
+			Id:             "item-" + uploads[i],
+			ContentDetails: &youtube.PlaylistItemContentDetails{VideoId: uploads[i]},
+		})
+	}
+	writeJSON(w, resp)
+}
+
+func (f *fakeYouTube) listVideos(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &youtube.VideoListResponse{}
+	_ = r.ParseForm()
+	for _, ids := range r.Form["id"] {
+		for _, id := range strings.Split(ids, ",") {
+			if v, ok := f.videos[id]; ok {
+				resp.Items = append(resp.Items, v)
+			}
+		}
+	}
+	writeJSON(w, resp)
+}
+
+func (f *fakeYouTube) listMessages(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	delay := f.listDelay
+	f.mu.Unlock()
+	time.Sleep(delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inFlight--
+	f.page++
+	liveChatID := r.FormValue("liveChatId")
+	items := f.pending
+	if _, ok := f.chatPending[liveChatID]; ok {
+		items = f.chatPending[liveChatID]
+		f.chatPending[liveChatID] = nil
+	} else {
+		f.pending = nil
+	}
+	resp := &youtube.LiveChatMessageListResponse{
+		Kind:                  "youtube#liveChatMessageListResponse",
+		Etag:                  fmt.Sprintf("etag-%d", f.page),
+		Items:                 items,
+		NextPageToken:         fmt.Sprintf("page-%d", f.page),
+		PollingIntervalMillis: f.pollInterval,
+		PageInfo:              &youtube.PageInfo{TotalResults: int64(len(items)), ResultsPerPage: int64(len(items))},
+	}
+	for _, item := range items {
+		// The parts that are returned by the API but not used by the events.
+		item.Kind, item.Etag = "youtube#liveChatMessage", "etag-"+item.Id
+		if item.Snippet != nil {
+			item.Snippet.LiveChatId = liveChatID
+			item.Snippet.HasDisplayContent = true
+		}
+	}
+	writeJSON(w, resp)
+}
+
+func (f *fakeYouTube) insertMessage(w http.ResponseWriter, r *http.Request) {
+	msg := &youtube.LiveChatMessage{}
+	if err := json.NewDecoder(r.Body).Decode(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.sent = append(f.sent, msg.Snippet.TextMessageDetails.MessageText)
+	f.mu.Unlock()
+	writeJSON(w, msg)
+}
+
+func (f *fakeYouTube) insertBan(w http.ResponseWriter, r *http.Request) {
+	ban := &youtube.LiveChatBan{}
+	if err := json.NewDecoder(r.Body).Decode(ban); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.bans = append(f.bans, ban.Snippet)
+	f.mu.Unlock()
+	writeJSON(w, ban)
+}
+
+func (f *fakeYouTube) bansIssued() []*youtube.LiveChatBanSnippet {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*youtube.LiveChatBanSnippet(nil), f.bans...)
+}
+
+func writeAPIError(w http.ResponseWriter, code int, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"message": reason,
+			"errors":  []map[string]any{{"reason": reason, "message": reason}},
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// textMessage returns a textMessageEvent as it is returned by liveChatMessages.list.
+func textMessage(id, author, text string) *youtube.LiveChatMessage {
+	return &youtube.LiveChatMessage{
+		Id: id,
+		Snippet: &youtube.LiveChatMessageSnippet{
+			Type:               "textMessageEvent",
+			PublishedAt:        time.Now().UTC().Format(time.RFC3339),
+			DisplayMessage:     text,
+			TextMessageDetails: &youtube.LiveChatTextMessageDetails{MessageText: text},
+		},
+		AuthorDetails: &youtube.LiveChatMessageAuthorDetails{
+			ChannelId:   "channel-" + author,
+			DisplayName: author,
+		},
+	}
+}
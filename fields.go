@@ -0,0 +1,25 @@
+package youtubelive
+
+import "google.golang.org/api/googleapi"
+
+// Partial response field masks of the requests, only what the events and lookups need is
+// requested to keep the responses small. See
+// https://developers.google.com/youtube/v3/getting-started#partial.
+const (
+	liveChatMessagesFields googleapi.Field = "nextPageToken,pollingIntervalMillis," +
+		"items(id,authorDetails(channelId,channelUrl,displayName,isChatModerator,isChatOwner,isChatSponsor,isVerified,profileImageUrl)," +
+		"snippet(type,publishedAt,authorChannelId,displayMessage,textMessageDetails,superChatDetails,superStickerDetails," +
+		"memberMilestoneChatDetails,membershipGiftingDetails,giftMembershipReceivedDetails,newSponsorDetails," +
+		"userBannedDetails,messageDeletedDetails,messageRetractedDetails,pollDetails))"
+	liveChatVideoFields   googleapi.Field = "items(snippet/channelId,liveStreamingDetails/activeLiveChatId)"
+	liveVideoFields       googleapi.Field = "items(id,snippet/liveBroadcastContent,liveStreamingDetails(actualStartTime,actualEndTime))"
+	uploadsPlaylistFields googleapi.Field = "items/contentDetails/relatedPlaylists/uploads"
+	playlistVideoFields   googleapi.Field = "items/contentDetails/videoId"
+	searchVideoFields     googleapi.Field = "items/id/videoId"
+	channelIDFields       googleapi.Field = "items/id"
+	channelTitleFields    googleapi.Field = "items(id,snippet/title)"
+)
+
+// maxVideoIDs is the most video IDs videos.list accepts in one request, and the most
+// items of a playlistItems.list page.
+const maxVideoIDs = 50
@@ -0,0 +1,71 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestYouTubeLive_CurrentBroadcastIDBatchesVideos(t *testing.T) {
+	fake := newFakeYouTube()
+	for i := range 30 {
+		fake.addUpload("channel", fmt.Sprintf("video-%d", i))
+	}
+	// An older upload is live, the most recent uploads are not.
+	fake.addLiveVideo("video-10", "chat-10")
+	yt := newTestYouTubeLive(t, fake)
+
+	broadcastID, err := yt.CurrentBroadcastIDFromChannelIDContext(context.Background(), "channel")
+	assert.NoError(t, err)
+	assert.Equal(t, "video-10", broadcastID)
+	assert.Equal(t, 1, fake.callCount("/youtube/v3/videos"), "one videos.list for every upload")
+
+	filtered, full := fake.responseBytes("/youtube/v3/playlistItems")
+	assert.Less(t, filtered, full/2)
+}
+
+func TestYouTubeLive_ChatFieldMask(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	superChat := textMessage("2", "fan", "")
+	superChat.Snippet.Type = "superChatEvent"
+	superChat.Snippet.TextMessageDetails = nil
+	superChat.Snippet.SuperChatDetails = &youtube.LiveChatSuperChatDetails{
+		AmountMicros:        5_000_000,
+		AmountDisplayString: "$5.00",
+		Currency:            "USD",
+		UserComment:         "great stream",
+		Tier:                2,
+	}
+	fake.queue(textMessage("1", "viewer", "hello"), superChat)
+	events, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	chat := nextChatMessage(t, events)
+	assert.Equal(t, "hello", chat.Message)
+	assert.Equal(t, "channel-viewer", chat.AuthorDetails.ChannelId)
+	if got, ok := (<-events).(*SuperChatEvent); assert.True(t, ok) {
+		assert.Equal(t, "great stream", got.Message)
+		assert.Equal(t, "USD", got.Currency)
+		assert.Equal(t, 5.0, got.Amount)
+		assert.Equal(t, int64(5_000_000), got.AmountMicros)
+		assert.Equal(t, "$5.00", got.AmountDisplayString)
+		assert.Equal(t, 2, got.Tier)
+	}
+	cancel()
+	for range events {
+	}
+
+	filtered, full := fake.responseBytes("/youtube/v3/liveChat/messages")
+	t.Logf("liveChatMessages.list responses: %d bytes, %d bytes without fields", filtered, full)
+	assert.Less(t, filtered, full*3/4)
+}
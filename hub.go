@@ -0,0 +1,358 @@
+package youtubelive
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HubEvent is a LiveEvent of one of the chats of a Hub.
+type HubEvent struct {
+	ChannelID   string
+	BroadcastID string
+	LiveChatID  string
+	Event       LiveEvent
+}
+
+type HubOption func(h *Hub)
+
+// HubConcurrency sets how many requests the Hub has in flight at most over all chats, the
+// default is 4.
+func HubConcurrency(n int) HubOption {
+	return func(h *Hub) {
+		h.concurrency = max(n, 1)
+	}
+}
+
+// HubBuffer sets the buffer of the merged event channel, the default is 100.
+func HubBuffer(size int) HubOption {
+	return func(h *Hub) {
+		h.buffer = max(size, 0)
+	}
+}
+
+// Hub polls many live chats from a single scheduler and merges their events into one
+// channel. Every chat is polled at the interval it asks for, with at most one request in
+// flight per chat, and the chat that has been due the longest is polled first when the
+// concurrency limit is reached. The events of every chat are buffered like those of
+// Attach, see EventBuffer and EventOverflow, but the chats are always polled as StreamChat
+// does not apply to a Hub.
+type Hub struct {
+	yt          *YouTubeLive
+	concurrency int
+	buffer      int
+	now         func() time.Time
+
+	events chan HubEvent
+	wake   chan struct{}
+	done   chan struct{}
+	sem    chan struct{}
+
+	mu      sync.Mutex
+	started bool
+	chats   map[string]*hubChat
+	queue   hubQueue
+	closed  bool
+	closing sync.WaitGroup
+}
+
+type hubChat struct {
+	broadcastID string
+	chat        liveChat
+	poll        pollKey
+	pageToken   string
+	interval    time.Duration
+	due         time.Time
+	index       int // in the queue, -1 while polled or removed
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// events is written by the polls and sends, out is read by forward, see
+	// newEventChannel.
+	events  chan LiveEvent
+	out     chan LiveEvent
+	active  sync.WaitGroup
+	removed bool
+}
+
+func NewHub(yt *YouTubeLive, options ...HubOption) *Hub {
+	h := &Hub{
+		yt:          yt,
+		concurrency: 4,
+		buffer:      100,
+		now:         time.Now,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		chats:       make(map[string]*hubChat),
+	}
+	for _, option := range options {
+		option(h)
+	}
+	h.events = make(chan HubEvent, h.buffer)
+	h.sem = make(chan struct{}, h.concurrency)
+	return h
+}
+
+// Events returns the merged events of all chats, it is closed when Run returns.
+func (h *Hub) Events() <-chan HubEvent {
+	return h.events
+}
+
+// Add attaches to the live chat of the broadcast, the chat is polled when Run is running.
+// A chat is removed when it ends.
+func (h *Hub) Add(ctx context.Context, broadcastID string) error {
+	chat, err := h.yt.getLiveChat(ctx, broadcastID)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return ErrHubClosed
+	}
+	if _, ok := h.chats[broadcastID]; ok {
+		return fmt.Errorf("%w: %s", ErrAlreadyAttached, broadcastID)
+	}
+	c := &hubChat{
+		broadcastID: broadcastID,
+		chat:        chat,
+		interval:    defaultPollInterval,
+		due:         h.now(),
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	// The queue is not stopped by c.ctx, forward drains it once the chat is removed.
+	var backlog func() int
+	c.events, c.out, backlog = h.yt.newEventChannel(context.Background())
+	h.yt.metrics.attach(c.out, backlog)
+	c.poll = h.yt.startPolling(chat.liveChatID)
+	h.chats[broadcastID] = c
+	heap.Push(&h.queue, c)
+	h.closing.Add(1)
+	go h.forward(c)
+	h.signal()
+	return nil
+}
+
+// Remove detaches from the chat of the broadcast, it reports whether the chat was
+// attached.
+func (h *Hub) Remove(broadcastID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.chats[broadcastID]
+	if ok {
+		h.removeLocked(c)
+	}
+	return ok
+}
+
+// Chats returns the broadcast IDs of the attached chats.
+func (h *Hub) Chats() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]string, 0, len(h.chats))
+	for id := range h.chats {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Send sends the BotEvent to the chat of the broadcast, after the OutgoingMiddleware. It
+// waits for a free request like the polls and failures are delivered as ErrorEvent.
+func (h *Hub) Send(ctx context.Context, broadcastID string, evt BotEvent) error {
+	h.mu.Lock()
+	c, ok := h.chats[broadcastID]
+	if !ok {
+		h.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNotAttached, broadcastID)
+	}
+	c.active.Add(1)
+	h.mu.Unlock()
+	defer c.active.Done()
+
+	select {
+	case h.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.ctx.Done():
+		return fmt.Errorf("%w: %s", ErrNotAttached, broadcastID)
+	}
+	defer func() { <-h.sem }()
+	for _, evt := range h.yt.applyBotMiddleware(evt) {
+		h.yt.sendBotEvent(c.ctx, c.chat.liveChatID, evt, c.events)
+	}
+	return nil
+}
+
+// Run schedules the polls of the chats until ctx is done, then every chat is removed and
+// the Events channel is closed. Run can only be called once, it returns ErrHubStarted
+// when called again.
+func (h *Hub) Run(ctx context.Context) error {
+	h.mu.Lock()
+	if h.started {
+		h.mu.Unlock()
+		return ErrHubStarted
+	}
+	h.started = true
+	h.mu.Unlock()
+	defer h.shutdown()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		h.mu.Lock()
+		var (
+			next *hubChat
+			due  time.Time
+		)
+		if len(h.queue) > 0 {
+			next, due = h.queue[0], h.queue[0].due
+		}
+		h.mu.Unlock()
+
+		if next == nil || due.After(h.now()) {
+			var wait <-chan time.Time
+			if next != nil {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(due.Sub(h.now()))
+				wait = timer.C
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-h.wake:
+			case <-wait:
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case h.sem <- struct{}{}:
+		}
+		h.mu.Lock()
+		if next.removed || next.index != 0 {
+			// Removed, or another chat was added before it, while waiting for a free
+			// request.
+			h.mu.Unlock()
+			<-h.sem
+			continue
+		}
+		heap.Remove(&h.queue, next.index)
+		next.active.Add(1)
+		h.mu.Unlock()
+		go h.poll(next)
+	}
+}
+
+func (h *Hub) poll(c *hubChat) {
+	defer c.active.Done()
+	// The request is released before the events are delivered, so a chat whose consumer
+	// is behind does not hold up the polls of the other chats.
+	pageToken, interval, ended := h.yt.pollOnce(c.ctx, c.poll, c.pageToken, c.interval, c.events, func() { <-h.sem })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.removed {
+		return
+	}
+	if ended {
+		h.removeLocked(c)
+		return
+	}
+	c.pageToken, c.interval = pageToken, interval
+	c.due = h.now().Add(interval)
+	heap.Push(&h.queue, c)
+	h.signal()
+}
+
+// forward tags the events of the chat for the merged channel until the chat is removed.
+func (h *Hub) forward(c *hubChat) {
+	defer h.closing.Done()
+	defer h.yt.metrics.detach(c.out)
+	for event := range c.out {
+		select {
+		case h.events <- HubEvent{
+			ChannelID:   c.chat.channelID,
+			BroadcastID: c.broadcastID,
+			LiveChatID:  c.chat.liveChatID,
+			Event:       event,
+		}:
+		case <-h.done:
+			// Drain so the chat can finish.
+		}
+	}
+}
+
+// removeLocked stops the chat, its events channel is closed once its poll and sends are
+// done. h.mu must be held.
+func (h *Hub) removeLocked(c *hubChat) {
+	if c.removed {
+		return
+	}
+	c.removed = true
+	c.cancel()
+	h.yt.forgetPollInterval(c.poll)
+	delete(h.chats, c.broadcastID)
+	if c.index >= 0 {
+		heap.Remove(&h.queue, c.index)
+	}
+	go func() {
+		c.active.Wait()
+		close(c.events)
+	}()
+}
+
+func (h *Hub) shutdown() {
+	h.mu.Lock()
+	h.closed = true
+	for _, c := range h.chats {
+		h.removeLocked(c)
+	}
+	h.mu.Unlock()
+	close(h.done)
+	h.closing.Wait()
+	close(h.events)
+}
+
+func (h *Hub) signal() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// hubQueue is a heap of the chats waiting for their next poll, the earliest due first.
+type hubQueue []*hubChat
+
+func (q hubQueue) Len() int { return len(q) }
+
+func (q hubQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+
+func (q hubQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *hubQueue) Push(x any) {
+	c := x.(*hubChat)
+	c.index = len(*q)
+	*q = append(*q, c)
+}
+
+func (q *hubQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	old[len(old)-1] = nil
+	c.index = -1
+	*q = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,170 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nextHubChat(t *testing.T, events <-chan HubEvent) HubEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("hub events closed")
+			}
+			if _, ok := event.Event.(*ChatMessageEvent); ok {
+				return event
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for a chat message")
+		}
+	}
+}
+
+func TestHub_MergesTaggedEvents(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo("video-a", "chat-a")
+	fake.addLiveVideo("video-b", "chat-b")
+	hub := NewHub(newTestYouTubeLive(t, fake))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, hub.Add(ctx, "video-a"))
+	assert.NoError(t, hub.Add(ctx, "video-b"))
+	assert.ErrorIs(t, hub.Add(ctx, "video-a"), ErrAlreadyAttached)
+	assert.ElementsMatch(t, []string{"video-a", "video-b"}, hub.Chats())
+
+	fake.queueFor("chat-a", textMessage("a1", "alice", "from a"))
+	fake.queueFor("chat-b", textMessage("b1", "bob", "from b"))
+	done := make(chan error)
+	go func() { done <- hub.Run(ctx) }()
+
+	got := map[string]HubEvent{}
+	for range 2 {
+		event := nextHubChat(t, hub.Events())
+		got[event.LiveChatID] = event
+	}
+	if assert.Contains(t, got, "chat-a") {
+		assert.Equal(t, "UC-video-a", got["chat-a"].ChannelID)
+		assert.Equal(t, "video-a", got["chat-a"].BroadcastID)
+		assert.Equal(t, "from a", got["chat-a"].Event.(*ChatMessageEvent).Message)
+	}
+	if assert.Contains(t, got, "chat-b") {
+		assert.Equal(t, "UC-video-b", got["chat-b"].ChannelID)
+		assert.Equal(t, "from b", got["chat-b"].Event.(*ChatMessageEvent).Message)
+	}
+
+	assert.ErrorIs(t, hub.Run(ctx), ErrHubStarted)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	for range hub.Events() {
+	}
+	assert.Empty(t, hub.Chats())
+	assert.ErrorIs(t, hub.Run(ctx), ErrHubStarted)
+	assert.ErrorIs(t, hub.Add(context.Background(), "video-a"), ErrHubClosed)
+}
+
+func TestHub_Concurrency(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.listDelay = 20 * time.Millisecond
+	hub := NewHub(newTestYouTubeLive(t, fake), HubConcurrency(2))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chats := []string{"chat-0", "chat-1", "chat-2", "chat-3", "chat-4", "chat-5"}
+	for _, liveChatID := range chats {
+		videoID := "video-" + liveChatID
+		fake.addLiveVideo(videoID, liveChatID)
+		assert.NoError(t, hub.Add(ctx, videoID))
+		fake.queueFor(liveChatID, textMessage(liveChatID, "user", "hello"))
+	}
+	go func() { _ = hub.Run(ctx) }()
+
+	seen := map[string]bool{}
+	for len(seen) < len(chats) {
+		seen[nextHubChat(t, hub.Events()).LiveChatID] = true
+	}
+	assert.LessOrEqual(t, fake.maxListsInFlight(), 2)
+	assert.Equal(t, 2, fake.maxListsInFlight())
+}
+
+func TestHub_SlowChatDoesNotHoldRequests(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo("video-a", "chat-a")
+	fake.addLiveVideo("video-b", "chat-b")
+	hub := NewHub(newTestYouTubeLive(t, fake), HubConcurrency(1), HubBuffer(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, hub.Add(ctx, "video-a"))
+	assert.NoError(t, hub.Add(ctx, "video-b"))
+	// More events than the chat buffers while nothing reads the events.
+	for i := range 150 {
+		fake.queueFor("chat-a", textMessage(fmt.Sprint("a", i), "alice", "from a"))
+	}
+	fake.queueFor("chat-b", textMessage("b1", "bob", "from b"))
+	go func() { _ = hub.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		return fake.callCount("/youtube/v3/liveChat/messages") >= 2
+	}, 2*time.Second, 5*time.Millisecond, "chat-b is polled while chat-a delivers")
+	cancel()
+	for range hub.Events() {
+	}
+}
+
+func TestHub_EventOverflow(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo("video-a", "chat-a")
+	hub := NewHub(newTestYouTubeLive(t, fake, EventBuffer(1), EventOverflow(OverflowDropNewest)), HubBuffer(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, hub.Add(ctx, "video-a"))
+	fake.queueFor("chat-a", textMessage("a1", "alice", "first"), textMessage("a2", "alice", "second"), textMessage("a3", "alice", "third"))
+	go func() { _ = hub.Run(ctx) }()
+	// The chat keeps being polled while nothing reads the events.
+	assert.Eventually(t, func() bool {
+		return fake.callCount("/youtube/v3/liveChat/messages") >= 3
+	}, 2*time.Second, 5*time.Millisecond)
+
+	// What the buffer of the chat cannot hold is dropped and reported.
+	var received, dropped int
+	for received+dropped < 3 {
+		switch event := (<-hub.Events()).Event.(type) {
+		case *ChatMessageEvent:
+			received++
+		case *EventsDroppedEvent:
+			dropped += event.Dropped
+		}
+	}
+	assert.Positive(t, dropped)
+	cancel()
+	for range hub.Events() {
+	}
+}
+
+func TestHub_SendAndRemove(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo("video-a", "chat-a")
+	hub := NewHub(newTestYouTubeLive(t, fake))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, hub.Add(ctx, "video-a"))
+	go func() { _ = hub.Run(ctx) }()
+
+	assert.NoError(t, hub.Send(ctx, "video-a", BotChatMessage{Message: "hi"}))
+	assert.Equal(t, []string{"hi"}, fake.sentMessages())
+
+	assert.True(t, hub.Remove("video-a"))
+	assert.False(t, hub.Remove("video-a"))
+	assert.ErrorIs(t, hub.Send(ctx, "video-a", BotChatMessage{Message: "bye"}), ErrNotAttached)
+	assert.Empty(t, hub.Chats())
+}
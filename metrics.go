@@ -0,0 +1,274 @@
+package youtubelive
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// quotaCosts are the quota units of the API methods, see
+// https://developers.google.com/youtube/v3/determine_quota_cost. Calls that failed with an
+// error response are counted as well because they use quota too, calls that got no
+// response, like network errors and canceled calls, are not.
+var quotaCosts = map[string]float64{
+	"channels.list":         1,
+	"playlistItems.list":    1,
+	"videos.list":           1,
+	"search.list":           100,
+	"liveChatMessages.list": 5,
+	// Each stream is charged like a list call, the stream delivers many pages for it.
+	"liveChatMessages.streamList": 5,
+	"liveChatMessages.insert":     50,
+	"liveChatMessages.delete":     50,
+	"liveChatBans.insert":         50,
+}
+
+// Metrics collects Prometheus metrics about chat ingestion and API usage, it is enabled
+// with the WithMetrics option. A Metrics can be shared by several YouTubeLive instances.
+type Metrics struct {
+	events       *prometheus.CounterVec
+	apiCalls     *prometheus.CounterVec
+	quotaUnits   *prometheus.CounterVec
+	sendFailures *prometheus.CounterVec
+	pollInterval *prometheus.GaugeVec
+	delivery     prometheus.Histogram
+	dropped      *prometheus.CounterVec
+
+	mu sync.Mutex
+	// attached are the backlogs of the attached event channels.
+	attached map[<-chan LiveEvent]func() int
+	// polled counts the pollers of each live chat, the poll interval of a chat is removed
+	// when its last poller stops.
+	polled map[string]int
+}
+
+// NewMetrics creates the metrics and registers them on registerer. The metrics are:
+//
+//	youtubelive_events_total{type}                   events delivered by event type
+//	youtubelive_api_calls_total{method,status}       API calls by method and HTTP or gRPC status
+//	youtubelive_quota_units_total{method}            quota units used by method
+//	youtubelive_send_failures_total{type}            BotEvents that could not be sent
+//	youtubelive_poll_interval_seconds{live_chat_id}  interval of the most recent poll of each polled chat
+//	youtubelive_event_backlog                        events waiting in attached event channels
+//	youtubelive_event_delivery_seconds               time from an event being published to it being delivered
+//	youtubelive_events_dropped_total{policy}         events dropped because the consumer fell behind
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "youtubelive",
+			Name:      "events_total",
+			Help:      "Live events delivered by event type.",
+		}, []string{"type"}),
+		apiCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "youtubelive",
+			Name:      "api_calls_total",
+			Help:      "YouTube API calls by method and status.",
+		}, []string{"method", "status"}),
+		quotaUnits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "youtubelive",
+			Name:      "quota_units_total",
+			Help:      "YouTube API quota units used by method.",
+		}, []string{"method"}),
+		sendFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "youtubelive",
+			Name:      "send_failures_total",
+			Help:      "Bot events that could not be sent to the live chat by bot event type.",
+		}, []string{"type"}),
+		pollInterval: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "youtubelive",
+			Name:      "poll_interval_seconds",
+			Help:      "Interval of the most recent poll by live chat.",
+		}, []string{"live_chat_id"}),
+		delivery: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "youtubelive",
+			Name:      "event_delivery_seconds",
+			Help:      "Time from a live event being published to it being delivered on the event channel.",
+			Buckets:   []float64{0.25, 0.5, 1, 2, 3, 5, 8, 13, 21, 34},
+		}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "youtubelive",
+			Name:      "events_dropped_total",
+			Help:      "Live events dropped because the consumer fell behind, by overflow policy.",
+		}, []string{"policy"}),
+		attached: make(map[<-chan LiveEvent]func() int),
+		polled:   make(map[string]int),
+	}
+	backlog := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "youtubelive",
+		Name:      "event_backlog",
+		Help:      "Live events waiting to be read from the attached event channels.",
+	}, m.backlog)
+
+	for _, c := range []prometheus.Collector{m.events, m.apiCalls, m.quotaUnits, m.sendFailures, m.pollInterval, m.delivery, m.dropped, backlog} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// The methods below are no-ops on a nil *Metrics so the metrics can be optional.
+
+func (m *Metrics) attach(out <-chan LiveEvent, backlog func() int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attached[out] = backlog
+}
+
+func (m *Metrics) detach(out <-chan LiveEvent) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attached, out)
+}
+
+func (m *Metrics) pollStarted(liveChatID string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.polled[liveChatID]++
+}
+
+func (m *Metrics) pollStopped(liveChatID string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.polled[liveChatID]--; m.polled[liveChatID] > 0 {
+		return
+	}
+	delete(m.polled, liveChatID)
+	m.pollInterval.DeleteLabelValues(liveChatID)
+}
+
+func (m *Metrics) backlog() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int
+	for _, backlog := range m.attached {
+		n += backlog()
+	}
+	return float64(n)
+}
+
+func (m *Metrics) observeEvent(event LiveEvent) {
+	if m == nil {
+		return
+	}
+	name, ok := EventTypeName(event)
+	if !ok {
+		name = "other"
+	}
+	m.events.WithLabelValues(name).Inc()
+	if ts, ok := EventTimestamp(event); ok && !ts.IsZero() {
+		m.delivery.Observe(time.Since(ts).Seconds())
+	}
+}
+
+func (m *Metrics) observeAPI(method string, err error) {
+	m.observeCall(method, err, answered(err))
+}
+
+// observeCall counts the call, and its quota when the API answered it.
+func (m *Metrics) observeCall(method string, err error, answered bool) {
+	if m == nil {
+		return
+	}
+	m.apiCalls.WithLabelValues(method, apiStatus(err)).Inc()
+	if cost, ok := quotaCosts[method]; ok && answered {
+		m.quotaUnits.WithLabelValues(method).Add(cost)
+	}
+}
+
+func (m *Metrics) observeSendFailure(evt BotEvent) {
+	if m == nil {
+		return
+	}
+	kind := "other"
+	switch evt.(type) {
+	case BotChatMessage:
+		kind = "chatMessage"
+	case BotDeleteMessage:
+		kind = "deleteMessage"
+	case BotBanUser:
+		kind = "banUser"
+	}
+	m.sendFailures.WithLabelValues(kind).Inc()
+}
+
+func (m *Metrics) observeDropped(policy OverflowPolicy) {
+	if m == nil {
+		return
+	}
+	m.dropped.WithLabelValues(policy.String()).Inc()
+}
+
+func (m *Metrics) observePollInterval(liveChatID string, interval time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// A poll finishing after its chat was detached must not bring the series back.
+	if m.polled[liveChatID] > 0 {
+		m.pollInterval.WithLabelValues(liveChatID).Set(interval.Seconds())
+	}
+}
+
+// apiStatus returns the HTTP status code of the error, the gRPC code of stream errors or
+// "error" when the request did not get a response.
+// answered reports whether the call that returned err got a response from the API.
+func answered(err error) bool {
+	if err == nil {
+		return true
+	}
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		return true
+	}
+	gErr := &googleapi.Error{}
+	if errors.As(err, &gErr) {
+		return gErr.Code != 0
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Canceled, codes.DeadlineExceeded, codes.Unavailable:
+			// Returned by the client when the call did not complete.
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func apiStatus(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		return strconv.Itoa(apiErr.StatusCode)
+	}
+	gErr := &googleapi.Error{}
+	if errors.As(err, &gErr) {
+		return strconv.Itoa(gErr.Code)
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code().String()
+	}
+	return "error"
+}
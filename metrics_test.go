@@ -0,0 +1,86 @@
+package youtubelive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetrics_Attach(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := NewMetrics(registry)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = NewMetrics(registry)
+	assert.Error(t, err, "metrics can only be registered once")
+
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake, WithMetrics(metrics))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, commands, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	fake.queue(textMessage("1", "viewer", "hello"))
+	nextChatMessage(t, events)
+	commands <- BotDeleteMessage{MessageID: "missing"}
+	for event := range events {
+		if _, ok := event.(*ErrorEvent); ok {
+			break
+		}
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.events.WithLabelValues("chatMessage")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiCalls.WithLabelValues("videos.list", "ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiCalls.WithLabelValues("liveChatMessages.delete", "405")))
+	assert.Equal(t, 50.0, testutil.ToFloat64(metrics.quotaUnits.WithLabelValues("liveChatMessages.delete")))
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.quotaUnits.WithLabelValues("liveChatMessages.list")), 5.0)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.sendFailures.WithLabelValues("deleteMessage")))
+	assert.Equal(t, (10 * time.Millisecond).Seconds(), testutil.ToFloat64(metrics.pollInterval.WithLabelValues(testLiveChatID)))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.delivery))
+
+	count, err := testutil.GatherAndCount(registry, "youtubelive_event_backlog")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	cancel()
+	for range events {
+	}
+	assert.Zero(t, testutil.CollectAndCount(metrics.pollInterval), "the series is removed on detach")
+	assert.Contains(t, quotaCosts, "liveChatMessages.streamList")
+}
+
+func TestMetrics_QuotaOnlyForResponses(t *testing.T) {
+	metrics, err := NewMetrics(prometheus.NewRegistry())
+	if !assert.NoError(t, err) {
+		return
+	}
+	fake := newFakeYouTube()
+	fake.addChannel("@someone", "UCsomeone")
+	yt := newTestYouTubeLive(t, fake, WithMetrics(metrics))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = yt.ChannelIDFromChannelHandleContext(ctx, "someone")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.apiCalls.WithLabelValues("channels.list", "error")))
+	assert.Zero(t, testutil.ToFloat64(metrics.quotaUnits.WithLabelValues("channels.list")), "a canceled call uses no quota")
+
+	fake.fail("/youtube/v3/channels", "forbidden")
+	_, err = yt.ChannelIDFromChannelHandle("someone")
+	assert.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.quotaUnits.WithLabelValues("channels.list")), "an error response uses quota")
+
+	assert.False(t, answered(status.Error(codes.Unavailable, "connection refused")))
+	assert.True(t, answered(status.Error(codes.PermissionDenied, "forbidden")))
+}
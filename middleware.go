@@ -0,0 +1,261 @@
+package youtubelive
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Middleware processes a LiveEvent before it is delivered. It returns the events to
+// deliver in its place: none to drop it, the same or a modified event, or several to fan
+// it out. The EventMiddleware of a YouTubeLive is called for one event at a time, also
+// when several chats are attached or a bot event fails to send, so it does not need its
+// own locking. Middleware shared between YouTubeLive instances or used with Pipe as well
+// must be safe for concurrent use.
+type Middleware func(event LiveEvent) []LiveEvent
+
+// BotMiddleware processes a BotEvent before it is sent to the live chat, the returned
+// events are sent in its place. Like Middleware, the OutgoingMiddleware of a YouTubeLive
+// is called for one event at a time.
+type BotMiddleware func(event BotEvent) []BotEvent
+
+// EventPredicate reports whether an event matches, it is used with Filter and Drop.
+type EventPredicate func(event LiveEvent) bool
+
+// Filter keeps only the events that match keep.
+func Filter(keep EventPredicate) Middleware {
+	return func(event LiveEvent) []LiveEvent {
+		if keep(event) {
+			return []LiveEvent{event}
+		}
+		return nil
+	}
+}
+
+// Drop removes the events that match drop.
+func Drop(drop EventPredicate) Middleware {
+	return Filter(Not(drop))
+}
+
+// Transform replaces every event with the result of fn, such as to normalize text or
+// enrich the author.
+func Transform(fn func(event LiveEvent) LiveEvent) Middleware {
+	return func(event LiveEvent) []LiveEvent {
+		return []LiveEvent{fn(event)}
+	}
+}
+
+// Not negates the predicate.
+func Not(predicate EventPredicate) EventPredicate {
+	return func(event LiveEvent) bool {
+		return !predicate(event)
+	}
+}
+
+// EventTypes matches events of the same type as one of the examples, such as
+// EventTypes(&ChatMessageEvent{}, &SuperChatEvent{}).
+func EventTypes(examples ...LiveEvent) EventPredicate {
+	types := make([]reflect.Type, 0, len(examples))
+	for _, example := range examples {
+		types = append(types, reflect.TypeOf(example))
+	}
+	return func(event LiveEvent) bool {
+		return slices.Contains(types, reflect.TypeOf(event))
+	}
+}
+
+// AuthorAtLeast matches events from an author with at least the permission. Events
+// without an author never match.
+func AuthorAtLeast(permission Permission) EventPredicate {
+	return func(event LiveEvent) bool {
+		author, ok := EventAuthor(event)
+		return ok && PermissionOf(author) >= permission
+	}
+}
+
+// AuthorIn matches events from one of the channel IDs, such as to drop blocked users.
+func AuthorIn(channelIDs ...string) EventPredicate {
+	return func(event LiveEvent) bool {
+		author, ok := EventAuthor(event)
+		return ok && slices.Contains(channelIDs, author.ChannelId)
+	}
+}
+
+// MessageMatches matches events with a message text that matches re.
+func MessageMatches(re *regexp.Regexp) EventPredicate {
+	return func(event LiveEvent) bool {
+		msg, ok := EventMessage(event)
+		return ok && re.MatchString(msg)
+	}
+}
+
+// EventAuthor returns the author of events that have one.
+func EventAuthor(event LiveEvent) (AuthorDetails, bool) {
+	switch e := event.(type) {
+	case *ChatMessageEvent:
+		return e.AuthorDetails, true
+	case *SuperChatEvent:
+		return e.AuthorDetails, true
+	case *SuperStickerEvent:
+		return e.AuthorDetails, true
+	case *MemberMilestoneEvent:
+		return e.AuthorDetails, true
+	case *MembershipGiftEvent:
+		return e.AuthorDetails, true
+	case *NewMemberEvent:
+		return e.AuthorDetails, true
+	case *MessageRetractedEvent:
+		return e.AuthorDetails, true
+	case *PollEvent:
+		return e.AuthorDetails, true
+	case *UnknownEvent:
+		return e.AuthorDetails, true
+	}
+	return AuthorDetails{}, false
+}
+
+// EventMessage returns the message text of events that have one.
+func EventMessage(event LiveEvent) (string, bool) {
+	switch e := event.(type) {
+	case *ChatMessageEvent:
+		return e.Message, true
+	case *SuperChatEvent:
+		return e.Message, true
+	}
+	return "", false
+}
+
+// EventTimestamp returns when the event was published, ErrorEvent returns when the error
+// occurred.
+func EventTimestamp(event LiveEvent) (time.Time, bool) {
+	switch e := event.(type) {
+	case *ChatMessageEvent:
+		return e.Timestamp, true
+	case *SuperChatEvent:
+		return e.Timestamp, true
+	case *SuperStickerEvent:
+		return e.Timestamp, true
+	case *MemberMilestoneEvent:
+		return e.Timestamp, true
+	case *MembershipGiftEvent:
+		return e.Timestamp, true
+	case *MembershipGiftReceivedEvent:
+		return e.Timestamp, true
+	case *NewMemberEvent:
+		return e.Timestamp, true
+	case *UserBannedEvent:
+		return e.Timestamp, true
+	case *MessageDeletedEvent:
+		return e.Timestamp, true
+	case *MessageRetractedEvent:
+		return e.Timestamp, true
+	case *MembersOnlyModeEvent:
+		return e.Timestamp, true
+	case *TombstoneEvent:
+		return e.Timestamp, true
+	case *PollEvent:
+		return e.Timestamp, true
+	case *UnknownEvent:
+		return e.Timestamp, true
+	case *ChatEndedEvent:
+		return e.Timestamp, true
+	case *ErrorEvent:
+		return e.Timestamp, true
+	case *EventsDroppedEvent:
+		return e.Timestamp, true
+	case *FirstMessageEvent:
+		return e.Timestamp, true
+	}
+	return time.Time{}, false
+}
+
+// Pipe runs the events through the middleware in order, it is for event channels that do
+// not come directly from Attach. The returned channel is closed when events is closed or
+// ctx is done.
+func Pipe(ctx context.Context, events <-chan LiveEvent, middleware ...Middleware) <-chan LiveEvent {
+	out := make(chan LiveEvent, cap(events))
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				for _, event := range applyMiddleware(middleware, event) {
+					select {
+					case out <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// FanOut copies every event to n channels, such as to run a WebhookSink next to the bot
+// itself. Every channel must be read, a slow reader holds back the others. The channels are
+// closed when events is closed or ctx is done.
+func FanOut(ctx context.Context, events <-chan LiveEvent, n int) []<-chan LiveEvent {
+	outs := make([]chan LiveEvent, n)
+	result := make([]<-chan LiveEvent, n)
+	for i := range outs {
+		outs[i] = make(chan LiveEvent, cap(events))
+		result[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				for _, out := range outs {
+					select {
+					case out <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return result
+}
+
+func applyMiddleware(middleware []Middleware, event LiveEvent) []LiveEvent {
+	events := []LiveEvent{event}
+	for _, mw := range middleware {
+		var next []LiveEvent
+		for _, event := range events {
+			next = append(next, mw(event)...)
+		}
+		events = next
+	}
+	return events
+}
+
+func applyBotMiddleware(middleware []BotMiddleware, event BotEvent) []BotEvent {
+	events := []BotEvent{event}
+	for _, mw := range middleware {
+		var next []BotEvent
+		for _, event := range events {
+			next = append(next, mw(event)...)
+		}
+		events = next
+	}
+	return events
+}
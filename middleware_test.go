@@ -0,0 +1,150 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_Predicates(t *testing.T) {
+	viewer := chatFrom(AuthorDetails{ChannelId: "UCviewer"}, "buy followers at spam.example")
+	member := chatFrom(AuthorDetails{ChannelId: "UCmember", IsChatSponsor: true}, "hello")
+	ended := &ChatEndedEvent{}
+
+	assert.True(t, EventTypes(&ChatMessageEvent{})(viewer))
+	assert.False(t, EventTypes(&ChatMessageEvent{})(ended))
+	assert.True(t, AuthorAtLeast(PermissionMember)(member))
+	assert.False(t, AuthorAtLeast(PermissionMember)(viewer))
+	assert.False(t, AuthorAtLeast(PermissionEveryone)(ended))
+	assert.True(t, AuthorIn("UCviewer")(viewer))
+	assert.True(t, MessageMatches(regexp.MustCompile(`spam\.example`))(viewer))
+	assert.False(t, MessageMatches(regexp.MustCompile(`.`))(ended))
+}
+
+func TestMiddleware_Pipeline(t *testing.T) {
+	events := make(chan LiveEvent, 10)
+	events <- chatFrom(AuthorDetails{ChannelId: "UCblocked"}, "hi")
+	events <- chatFrom(AuthorDetails{ChannelId: "UCviewer"}, "  HELLO ")
+	events <- &ChatEndedEvent{}
+	close(events)
+
+	out := Pipe(context.Background(), events,
+		Drop(AuthorIn("UCblocked")),
+		Transform(func(event LiveEvent) LiveEvent {
+			if e, ok := event.(*ChatMessageEvent); ok {
+				normalized := *e
+				normalized.Message = strings.ToLower(strings.TrimSpace(e.Message))
+				return &normalized
+			}
+			return event
+		}),
+		func(event LiveEvent) []LiveEvent {
+			// Fan out chat messages to a second copy.
+			if _, ok := event.(*ChatMessageEvent); ok {
+				return []LiveEvent{event, event}
+			}
+			return []LiveEvent{event}
+		},
+	)
+
+	var got []LiveEvent
+	for event := range out {
+		got = append(got, event)
+	}
+	if assert.Len(t, got, 3) {
+		assert.Equal(t, "hello", got[0].(*ChatMessageEvent).Message)
+		assert.Same(t, got[0], got[1])
+		assert.IsType(t, &ChatEndedEvent{}, got[2])
+	}
+}
+
+func TestYouTubeLive_AttachMiddleware(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake,
+		EventMiddleware(Drop(AuthorIn("channel-blocked"))),
+		OutgoingMiddleware(func(event BotEvent) []BotEvent {
+			if msg, ok := event.(BotChatMessage); ok && strings.HasPrefix(msg.Message, "secret") {
+				return nil
+			}
+			return []BotEvent{event}
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, commands, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	fake.queue(textMessage("1", "blocked", "spam"), textMessage("2", "viewer", "hello"))
+	assert.Equal(t, "hello", nextChatMessage(t, events).Message)
+
+	commands <- BotChatMessage{Message: "secret"}
+	commands <- BotChatMessage{Message: "public"}
+	assert.Eventually(t, func() bool {
+		return len(fake.sentMessages()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"public"}, fake.sentMessages())
+}
+
+func TestYouTubeLive_MiddlewareSerialized(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	fake.fail("/youtube/v3/liveChat/bans", ReasonForbidden)
+	// seen is not guarded, the race detector catches concurrent calls.
+	var seen int
+	yt := newTestYouTubeLive(t, fake, EventMiddleware(func(event LiveEvent) []LiveEvent {
+		seen++
+		return []LiveEvent{event}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, commands, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	const n = 10
+	for i := range n {
+		fake.queue(textMessage(fmt.Sprint(i), "viewer", "hello"))
+		commands <- BotBanUser{ChannelID: "UCspam"}
+	}
+	var chats, errs int
+	for chats < n || errs < n {
+		switch (<-events).(type) {
+		case *ChatMessageEvent:
+			chats++
+		case *ErrorEvent:
+			errs++
+		}
+	}
+	cancel()
+	for range events {
+	}
+	assert.GreaterOrEqual(t, seen, 2*n)
+}
+
+func TestFanOut(t *testing.T) {
+	events := make(chan LiveEvent, 2)
+	events <- chatFrom(AuthorDetails{}, "one")
+	events <- chatFrom(AuthorDetails{}, "two")
+	close(events)
+
+	outs := FanOut(context.Background(), events, 2)
+	var first []LiveEvent
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		first = collect(outs[0])
+	}()
+	second := collect(outs[1])
+	<-done
+	assert.Len(t, first, 2)
+	assert.Equal(t, first, second)
+}
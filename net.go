@@ -6,9 +6,13 @@ import (
 	"net"
 	"sort"
 	"strconv"
+	"sync"
 )
 
+// listenResolve is shared between every ytClient of a YouTubeLive so the OAuth2 callback
+// port is only opened once.
 type listenResolve struct {
+	mu            sync.Mutex
 	listenAddr    string
 	stickyPort    net.Listener
 	effectiveAddr string
@@ -119,7 +123,21 @@ func selectBestIP(public, private, loopback []net.IP) net.IP {
 	return net.IPv4(127, 0, 0, 1)
 }
 
+func (yt *listenResolve) addr() string {
+	yt.mu.Lock()
+	defer yt.mu.Unlock()
+	return yt.effectiveAddr
+}
+
+func (yt *listenResolve) listener() net.Listener {
+	yt.mu.Lock()
+	defer yt.mu.Unlock()
+	return yt.stickyPort
+}
+
 func (yt *listenResolve) setupListener() error {
+	yt.mu.Lock()
+	defer yt.mu.Unlock()
 	if yt.stickyPort != nil {
 		return nil
 	}
@@ -3,10 +3,8 @@ package youtubelive
 import (
 	"context"
 	"errors"
-	"fmt"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/authhandler"
-	"strings"
 )
 
 func RefreshTokenSourceWithPKCE(ctx context.Context, config *oauth2.Config, token *oauth2.Token, state string, authHandler authhandler.AuthorizationHandler, pkce *authhandler.PKCEParams, opts ...oauth2.AuthCodeOption) oauth2.TokenSource {
@@ -69,13 +67,3 @@ func (source *authHandlerSource) Token() (*oauth2.Token, error) {
 	source.used = true
 	return t, nil
 }
-
-func wrapOauthErrors(err error) error {
-	if err == nil {
-		return nil
-	}
-	if strings.Contains(err.Error(), "oauth2:") {
-		return fmt.Errorf("%w: %w", NotLoggedIn, err)
-	}
-	return err
-}
@@ -1,7 +1,12 @@
 package youtubelive
 
 import (
+	"fmt"
 	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
 type Option func(*YouTubeLive) error
@@ -31,6 +36,44 @@ func RefreshToken(refreshToken string) Option {
 	}
 }
 
+// ReadCredentials option spreads read only requests, such as polling live chat and
+// looking up channels and videos, over the given credentials and fails over to the next
+// credential when one exceeds its quota. Requests that write, or that are about the
+// logged-in user, always use the bot identity given by RefreshToken or the login
+// workflow.
+func ReadCredentials(credentials ...Credential) Option {
+	return func(yt *YouTubeLive) error {
+		if len(credentials) == 0 {
+			return ErrInvalidCredential
+		}
+		for _, credential := range credentials {
+			if credential.APIKey == "" && credential.RefreshToken == "" {
+				return ErrInvalidCredential
+			}
+		}
+		yt.readCredentials = append(yt.readCredentials, credentials...)
+		return nil
+	}
+}
+
+// APIKey option uses the API key for public lookups, such as
+// ChannelIDFromChannelHandle, IsLive and CurrentBroadcastIDFromChannelID, so they work
+// without the user's consent. Live chat is read with the key only while no user is logged
+// in, as the key cannot read members-only or private chats; with a refresh token, given or
+// obtained through the login workflow, the chat is read as that user. Requests that need a
+// logged-in user, like LoggedInChannel or sending chat messages, return NotLoggedIn
+// without one. ReadCredentials take precedence over the key for every read when both
+// options are used, the key is then only used once the ReadCredentials are exhausted.
+func APIKey(key string) Option {
+	return func(yt *YouTubeLive) error {
+		if key == "" {
+			return ErrInvalidCredential
+		}
+		yt.apiKey = key
+		return nil
+	}
+}
+
 func OathListenAddr(oathListenAddr string) Option {
 	return func(yt *YouTubeLive) error {
 		yt.listenAddr = oathListenAddr
@@ -65,3 +108,145 @@ func OnNewRefreshToken(onNewRefreshToken func(refreshToken string)) Option {
 		return nil
 	}
 }
+
+// StreamChat option makes Attach receive live chat through the server streaming
+// liveChatMessages.streamList endpoint instead of polling, which lowers the latency and
+// the quota used. Attach falls back to polling when the endpoint is unavailable. The
+// events are the same with either transport.
+func StreamChat() Option {
+	return func(yt *YouTubeLive) error {
+		yt.streamChat = true
+		return nil
+	}
+}
+
+// StreamListEndpoint overrides the gRPC address and dial options used by StreamChat, such
+// as to use a local fake server for tests. TLS with the system certificates is used when
+// no dial options are given.
+func StreamListEndpoint(addr string, dialOptions ...grpc.DialOption) Option {
+	return func(yt *YouTubeLive) error {
+		yt.streamListAddr = addr
+		yt.streamListDialOptions = dialOptions
+		return nil
+	}
+}
+
+// EventMiddleware adds middleware that every LiveEvent of an attached chat goes through,
+// in the given order, before it is delivered on the channel returned by Attach.
+func EventMiddleware(middleware ...Middleware) Option {
+	return func(yt *YouTubeLive) error {
+		yt.middleware = append(yt.middleware, middleware...)
+		return nil
+	}
+}
+
+// OutgoingMiddleware adds middleware that every BotEvent written to the channel returned
+// by Attach goes through, in the given order, before it is sent to the live chat.
+func OutgoingMiddleware(middleware ...BotMiddleware) Option {
+	return func(yt *YouTubeLive) error {
+		yt.botMiddleware = append(yt.botMiddleware, middleware...)
+		return nil
+	}
+}
+
+// RecordPages writes every raw page of chat messages received by attached chats to the
+// recorder, see ReplayPages.
+func RecordPages(recorder *Recorder) Option {
+	return func(yt *YouTubeLive) error {
+		yt.pageRecorder = recorder
+		return nil
+	}
+}
+
+// NoSearchFallback makes FindLiveBroadcast, and the other lookups of the current
+// broadcast, only check the recent uploads of the channel. It saves the 100 quota units of
+// search.list when a channel is not live, at the cost of missing broadcasts that are not
+// in the uploads.
+func NoSearchFallback() Option {
+	return func(yt *YouTubeLive) error {
+		yt.searchFallback = false
+		return nil
+	}
+}
+
+// PollIntervalRange limits the interval between the polls of attached chats, a bound of 0
+// is not limited. A minimum below the interval the server asks for lowers the latency but
+// uses more quota, the server may reject polls that are too frequent.
+func PollIntervalRange(minInterval, maxInterval time.Duration) Option {
+	return func(yt *YouTubeLive) error {
+		if maxInterval > 0 && minInterval > maxInterval {
+			return fmt.Errorf("%w: %v > %v", ErrInvalidPollInterval, minInterval, maxInterval)
+		}
+		yt.minPollInterval = max(minInterval, 0)
+		yt.maxPollInterval = max(maxInterval, 0)
+		return nil
+	}
+}
+
+// QuotaSaver adapts the poll interval of attached chats to how busy they are. The interval
+// doubles with every poll without messages, up to the maximum of PollIntervalRange or 30
+// seconds, and is halved from the one the server asks for while a poll returns 20 or more
+// messages. See SetPollInterval to override the interval of a single chat.
+func QuotaSaver() Option {
+	return func(yt *YouTubeLive) error {
+		yt.quotaSaver = true
+		return nil
+	}
+}
+
+// EventBuffer sets how many events of an attached chat are buffered for a consumer that
+// falls behind, the default is 100. See EventOverflow for what happens when it is full.
+func EventBuffer(size int) Option {
+	return func(yt *YouTubeLive) error {
+		yt.eventBuffer = max(size, 0)
+		return nil
+	}
+}
+
+// EventOverflow sets the OverflowPolicy of the event channel of attached chats, the
+// default is OverflowBlock. With the other policies the chat is always received without
+// waiting for the consumer and an EventsDroppedEvent reports the events that were dropped.
+func EventOverflow(policy OverflowPolicy) Option {
+	return func(yt *YouTubeLive) error {
+		yt.overflow = policy
+		return nil
+	}
+}
+
+// SpillDir sets the directory of the temporary files of OverflowSpill, the default is
+// os.TempDir. Spilled events are encoded with MarshalEvent, so an ErrorEvent only keeps
+// the message of its error.
+func SpillDir(dir string) Option {
+	return func(yt *YouTubeLive) error {
+		yt.spillDir = dir
+		return nil
+	}
+}
+
+// WithBanLedger records the bans seen in attached chats and the bans issued with
+// BotBanUser or BanUser in the ledger.
+func WithBanLedger(ledger *BanLedger) Option {
+	return func(yt *YouTubeLive) error {
+		yt.banLedger = ledger
+		return nil
+	}
+}
+
+// WithMetrics records Prometheus metrics about chat ingestion and API usage, see
+// NewMetrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(yt *YouTubeLive) error {
+		yt.metrics = metrics
+		return nil
+	}
+}
+
+// WithTracing creates OpenTelemetry spans with the provider for Attach, every chat poll and
+// every BotEvent sent. The spans of BotEvents are linked to the span that delivered the
+// chat message they reply to, see BotChatMessage.ReplyTo.
+func WithTracing(provider trace.TracerProvider) Option {
+	return func(yt *YouTubeLive) error {
+		yt.tracer = provider.Tracer(tracerName)
+		return nil
+	}
+}
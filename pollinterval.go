@@ -0,0 +1,135 @@
+package youtubelive
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultQuotaSaverMax is the longest interval of QuotaSaver without PollIntervalRange.
+	defaultQuotaSaverMax = 30 * time.Second
+	// busyChatItems is the page size from which QuotaSaver considers a chat busy.
+	busyChatItems = 20
+)
+
+// pollKey identifies one poller of a live chat, the same chat can be attached more than
+// once.
+type pollKey struct {
+	liveChatID string
+	id         uint64
+}
+
+// pollIntervals holds the per chat overrides, keyed by live chat ID, and the effective poll
+// interval of every poller.
+type pollIntervals struct {
+	mu        sync.Mutex
+	overrides map[string]time.Duration
+	current   map[pollKey]time.Duration
+	lastID    uint64
+}
+
+func newPollIntervals() *pollIntervals {
+	return &pollIntervals{
+		overrides: make(map[string]time.Duration),
+		current:   make(map[pollKey]time.Duration),
+	}
+}
+
+// SetPollInterval polls the live chat at a fixed interval, ignoring the interval asked for
+// by the server, PollIntervalRange and QuotaSaver. An interval of 0 removes the override.
+// It takes effect from the next poll, see LiveChatIDContext for the live chat ID of a
+// broadcast.
+func (yt *YouTubeLive) SetPollInterval(liveChatID string, interval time.Duration) {
+	yt.pollIntervals.mu.Lock()
+	defer yt.pollIntervals.mu.Unlock()
+	if interval <= 0 {
+		delete(yt.pollIntervals.overrides, liveChatID)
+		return
+	}
+	yt.pollIntervals.overrides[liveChatID] = interval
+}
+
+// CurrentPollInterval returns the interval until the next poll of the live chat, it
+// returns false when the chat is not being polled. When the chat is attached more than
+// once the shortest interval is returned.
+func (yt *YouTubeLive) CurrentPollInterval(liveChatID string) (time.Duration, bool) {
+	yt.pollIntervals.mu.Lock()
+	defer yt.pollIntervals.mu.Unlock()
+	var (
+		shortest time.Duration
+		found    bool
+	)
+	for key, interval := range yt.pollIntervals.current {
+		if key.liveChatID == liveChatID && (!found || interval < shortest) {
+			shortest, found = interval, true
+		}
+	}
+	return shortest, found
+}
+
+// startPolling registers a poller of the chat, its interval is removed again with
+// forgetPollInterval.
+func (yt *YouTubeLive) startPolling(liveChatID string) pollKey {
+	yt.pollIntervals.mu.Lock()
+	yt.pollIntervals.lastID++
+	key := pollKey{liveChatID: liveChatID, id: yt.pollIntervals.lastID}
+	yt.pollIntervals.mu.Unlock()
+	yt.metrics.pollStarted(liveChatID)
+	return key
+}
+
+// nextPollInterval returns the interval until the next poll of the chat after a poll that
+// returned items messages. server is the interval asked for by the server and previous is
+// the interval before the poll.
+func (yt *YouTubeLive) nextPollInterval(poll pollKey, previous, server time.Duration, items int) time.Duration {
+	yt.pollIntervals.mu.Lock()
+	defer yt.pollIntervals.mu.Unlock()
+	interval, ok := yt.pollIntervals.overrides[poll.liveChatID]
+	if !ok {
+		interval = yt.adaptPollInterval(previous, server, items)
+	}
+	yt.pollIntervals.current[poll] = interval
+	return interval
+}
+
+// failedPollInterval returns the interval until the next poll of the chat after a failed
+// poll, the override of the chat or else previous.
+func (yt *YouTubeLive) failedPollInterval(poll pollKey, previous time.Duration) time.Duration {
+	yt.pollIntervals.mu.Lock()
+	defer yt.pollIntervals.mu.Unlock()
+	interval, ok := yt.pollIntervals.overrides[poll.liveChatID]
+	if !ok {
+		interval = previous
+	}
+	yt.pollIntervals.current[poll] = interval
+	return interval
+}
+
+func (yt *YouTubeLive) adaptPollInterval(previous, server time.Duration, items int) time.Duration {
+	interval := server
+	maxInterval := yt.maxPollInterval
+	if yt.quotaSaver {
+		if maxInterval == 0 {
+			maxInterval = max(defaultQuotaSaverMax, server)
+		}
+		switch {
+		case items == 0:
+			// Back off while the chat is quiet.
+			interval = max(server, 2*previous)
+		case items >= busyChatItems:
+			interval = server / 2
+		}
+	}
+	if maxInterval > 0 {
+		interval = min(interval, maxInterval)
+	}
+	return max(interval, yt.minPollInterval)
+}
+
+// forgetPollInterval removes the effective interval of a poller that stopped.
+func (yt *YouTubeLive) forgetPollInterval(poll pollKey) {
+	yt.pollIntervals.mu.Lock()
+	delete(yt.pollIntervals.current, poll)
+	yt.pollIntervals.mu.Unlock()
+	yt.metrics.pollStopped(poll.liveChatID)
+}
@@ -0,0 +1,119 @@
+package youtubelive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYouTubeLive_NextPollInterval(t *testing.T) {
+	const server = 2 * time.Second
+	tests := []struct {
+		name     string
+		options  []Option
+		previous time.Duration
+		items    int
+		want     time.Duration
+	}{
+		{"server interval", nil, 10 * time.Second, 0, server},
+		{"minimum", []Option{PollIntervalRange(3*time.Second, 0)}, server, 5, 3 * time.Second},
+		{"maximum", []Option{PollIntervalRange(0, time.Second)}, server, 5, time.Second},
+		{"quiet backs off", []Option{QuotaSaver()}, 4 * time.Second, 0, 8 * time.Second},
+		{"quiet backs off to the maximum", []Option{QuotaSaver()}, 20 * time.Second, 0, defaultQuotaSaverMax},
+		{"quiet backs off to the range", []Option{QuotaSaver(), PollIntervalRange(0, 5*time.Second)}, 4 * time.Second, 0, 5 * time.Second},
+		{"active uses the server interval", []Option{QuotaSaver()}, 16 * time.Second, 3, server},
+		{"busy tightens", []Option{QuotaSaver()}, server, busyChatItems, server / 2},
+		{"busy tightens to the minimum", []Option{QuotaSaver(), PollIntervalRange(1500*time.Millisecond, 0)}, server, busyChatItems, 1500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yt := newTestYouTubeLive(t, newFakeYouTube(), tt.options...)
+			assert.Equal(t, tt.want, yt.nextPollInterval(yt.startPolling("chat"), tt.previous, server, tt.items))
+			current, ok := yt.CurrentPollInterval("chat")
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, current)
+		})
+	}
+}
+
+func TestPollIntervalRange_Invalid(t *testing.T) {
+	_, err := NewYouTubeLive("client", "secret", PollIntervalRange(time.Second, time.Millisecond))
+	assert.ErrorIs(t, err, ErrInvalidPollInterval)
+}
+
+func TestYouTubeLive_SetPollInterval(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake, QuotaSaver())
+	yt.SetPollInterval(testLiveChatID, 25*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	fake.queue(textMessage("1", "viewer", "hello"))
+	nextChatMessage(t, events)
+	current, ok := yt.CurrentPollInterval(testLiveChatID)
+	assert.True(t, ok)
+	assert.Equal(t, 25*time.Millisecond, current)
+
+	yt.SetPollInterval(testLiveChatID, 0)
+	assert.Eventually(t, func() bool {
+		current, _ := yt.CurrentPollInterval(testLiveChatID)
+		return current != 25*time.Millisecond
+	}, 2*time.Second, 5*time.Millisecond)
+
+	cancel()
+	for range events {
+	}
+	assert.Eventually(t, func() bool {
+		_, ok := yt.CurrentPollInterval(testLiveChatID)
+		return !ok
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestYouTubeLive_PollIntervalPerAttachment(t *testing.T) {
+	yt := newTestYouTubeLive(t, newFakeYouTube())
+	first, second := yt.startPolling("chat"), yt.startPolling("chat")
+	yt.nextPollInterval(first, 0, time.Second, 1)
+	yt.nextPollInterval(second, 0, 2*time.Second, 1)
+	current, _ := yt.CurrentPollInterval("chat")
+	assert.Equal(t, time.Second, current, "the shortest interval")
+
+	yt.forgetPollInterval(first)
+	current, ok := yt.CurrentPollInterval("chat")
+	assert.True(t, ok, "the other attachment is still polling")
+	assert.Equal(t, 2*time.Second, current)
+
+	yt.forgetPollInterval(second)
+	_, ok = yt.CurrentPollInterval("chat")
+	assert.False(t, ok)
+}
+
+func TestYouTubeLive_SetPollIntervalAfterFailedPoll(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	fake.fail("/youtube/v3/liveChat/messages", "backendError")
+	yt := newTestYouTubeLive(t, fake)
+	yt.SetPollInterval(testLiveChatID, 25*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.IsType(t, &ErrorEvent{}, <-events)
+	assert.Eventually(t, func() bool {
+		current, _ := yt.CurrentPollInterval(testLiveChatID)
+		return current == 25*time.Millisecond
+	}, 2*time.Second, 5*time.Millisecond, "the override applies while the polls fail")
+
+	cancel()
+	for range events {
+	}
+}
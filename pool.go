@@ -0,0 +1,196 @@
+package youtubelive
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Credential is an identity used for read only requests, see ReadCredentials. Either
+// RefreshToken or APIKey should be set.
+type Credential struct {
+	RefreshToken string
+	APIKey       string
+}
+
+// failedCredentialBackoff is how long a credential that could not be used for a reason
+// other than its quota, such as a revoked refresh token, is skipped.
+const failedCredentialBackoff = time.Minute
+
+type pooledCredential struct {
+	// index identifies the credential in logs without leaking the secret.
+	index         int
+	client        *ytClient
+	apiKey        string
+	apiKeyService *youtube.Service
+
+	// exhaustedUntil is when the credential is used again after it exceeded its quota or
+	// failed.
+	exhaustedUntil time.Time
+}
+
+func (c *pooledCredential) service() (*youtube.Service, error) {
+	if c.apiKeyService != nil {
+		return c.apiKeyService, nil
+	}
+	service, err := c.client.youtubeService()
+	return service, wrapAPIError("", err)
+}
+
+// credentialPool spreads read only requests round-robin over several credentials and
+// skips credentials that ran out of quota until the quota resets.
+type credentialPool struct {
+	mu          sync.Mutex
+	log         *slog.Logger
+	credentials []*pooledCredential
+	next        int
+	now         func() time.Time
+}
+
+func (yt *YouTubeLive) newCredentialPool(credentials []Credential) (*credentialPool, error) {
+	pool := &credentialPool{
+		log: yt.log,
+		now: time.Now,
+	}
+	for i, credential := range credentials {
+		pc := &pooledCredential{index: i}
+		switch {
+		case credential.APIKey != "":
+			service, err := newAPIKeyService(yt.ctx, yt.transport, credential.APIKey)
+			if err != nil {
+				return nil, err
+			}
+			pc.apiKey = credential.APIKey
+			pc.apiKeyService = service
+		case credential.RefreshToken != "":
+			pc.client = yt.newYtClient(credential.RefreshToken)
+			// Pooled credentials must never start the interactive workflow or report
+			// tokens that belong to the bot identity, they only refresh their token so
+			// they have no OAuth2 listener.
+			pc.client.autoAuth = false
+			pc.client.onNewRefreshToken = nil
+			pc.client.listenR = nil
+		default:
+			return nil, ErrInvalidCredential
+		}
+		pool.credentials = append(pool.credentials, pc)
+	}
+	return pool, nil
+}
+
+// do runs the call for operation with the next available credential, failing over to the
+// following one when a credential has exceeded its quota or cannot be used, such as a
+// refresh token that was revoked. ErrCredentialsExhausted is returned when no credential
+// is left.
+func (p *credentialPool) do(operation string, call func(service *youtube.Service) error) error {
+	var lastErr error
+	for range p.credentials {
+		credential := p.pick()
+		if credential == nil {
+			break
+		}
+		service, err := credential.service()
+		if err != nil {
+			p.fail(credential, err)
+			lastErr = err
+			continue
+		}
+		err = wrapAPIError(operation, call(service))
+		switch {
+		case IsQuotaExceeded(err):
+			p.exhaust(credential)
+		case errors.Is(err, NotLoggedIn):
+			// The token of a refresh token credential is fetched by the call.
+			p.fail(credential, err)
+		default:
+			return err
+		}
+		lastErr = err
+	}
+	if lastErr != nil {
+		return errors.Join(ErrCredentialsExhausted, lastErr)
+	}
+	return ErrCredentialsExhausted
+}
+
+// apiKey returns an API key of the pool that has not exceeded its quota, or blank if
+// there is none.
+func (p *credentialPool) apiKey() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for _, credential := range p.credentials {
+		if credential.apiKey != "" && now.After(credential.exhaustedUntil) {
+			return credential.apiKey
+		}
+	}
+	return ""
+}
+
+func (p *credentialPool) pick() *pooledCredential {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for range p.credentials {
+		credential := p.credentials[p.next]
+		p.next = (p.next + 1) % len(p.credentials)
+		if now.After(credential.exhaustedUntil) {
+			return credential
+		}
+	}
+	return nil
+}
+
+func (p *credentialPool) exhaust(credential *pooledCredential) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	credential.exhaustedUntil = nextQuotaReset(p.now())
+	p.log.Warn("read credential exceeded quota", "credential", credential.index, "until", credential.exhaustedUntil)
+}
+
+func (p *credentialPool) fail(credential *pooledCredential, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	credential.exhaustedUntil = p.now().Add(failedCredentialBackoff)
+	p.log.Warn("read credential failed", "credential", credential.index, "until", credential.exhaustedUntil, "error", err)
+}
+
+// nextQuotaReset returns the next midnight Pacific Time which is when YouTube resets the
+// daily quota.
+func nextQuotaReset(now time.Time) time.Time {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		loc = time.FixedZone("PST", -8*60*60)
+	}
+	now = now.In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+}
+
+func newAPIKeyService(ctx context.Context, transport http.RoundTripper, key string) (*youtube.Service, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	// option.WithAPIKey is ignored when a http client is given, so the key is added by the
+	// transport instead.
+	c := &http.Client{Transport: &apiKeyTransport{key: key, base: transport}}
+	return youtube.NewService(ctx, option.WithHTTPClient(c))
+}
+
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	q := r.URL.Query()
+	q.Set("key", t.key)
+	r.URL.RawQuery = q.Encode()
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,62 @@
+package youtubelive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialPool_FailsOverOnQuotaExceeded(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addChannel("@someone", "UCsomeone")
+	fake.exhaust("access-pooled")
+	yt := newTestYouTubeLive(t, fake, ReadCredentials(
+		Credential{RefreshToken: "pooled"},
+		Credential{APIKey: "key"},
+	))
+
+	for i := 0; i < 3; i++ {
+		channelID, err := yt.ChannelIDFromChannelHandle("someone")
+		assert.NoError(t, err)
+		assert.Equal(t, "UCsomeone", channelID)
+	}
+	// The exhausted credential is only tried once and the bot identity is never used for
+	// reads.
+	assert.Equal(t, []string{"access-pooled", "key", "key", "key"}, fake.authorizationsFor("/youtube/v3/channels"))
+
+	fake.exhaust("key")
+	_, err := yt.ChannelIDFromChannelHandle("someone")
+	assert.ErrorIs(t, err, ErrCredentialsExhausted)
+}
+
+func TestCredentialPool_FailsOverOnInvalidCredential(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addChannel("@someone", "UCsomeone")
+	yt := newTestYouTubeLive(t, fake, ReadCredentials(
+		Credential{RefreshToken: "revoked"},
+		Credential{APIKey: "key"},
+	))
+	assert.Nil(t, yt.pool.credentials[0].client.listenR, "pooled clients have no OAuth2 listener")
+
+	for i := 0; i < 2; i++ {
+		channelID, err := yt.ChannelIDFromChannelHandle("someone")
+		assert.NoError(t, err)
+		assert.Equal(t, "UCsomeone", channelID)
+	}
+	assert.Equal(t, []string{"key", "key"}, fake.authorizationsFor("/youtube/v3/channels"))
+	assert.Equal(t, 1, fake.callCount("/token"), "the failed credential is skipped afterwards")
+}
+
+func TestCredentialPool_WritesUseBotIdentity(t *testing.T) {
+	fake := newFakeYouTube()
+	yt := newTestYouTubeLive(t, fake, ReadCredentials(Credential{APIKey: "key"}))
+
+	assert.NoError(t, yt.sendChatMessage(context.Background(), testLiveChatID, "hello"))
+	assert.Equal(t, []string{"access-first"}, fake.authorizationsFor("/youtube/v3/liveChat/messages"))
+}
+
+func TestReadCredentials_Invalid(t *testing.T) {
+	_, err := NewYouTubeLive("client", "secret", ReadCredentials(Credential{}))
+	assert.ErrorIs(t, err, ErrInvalidCredential)
+}
@@ -0,0 +1,248 @@
+package youtubelive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// recordEntry is a line of a recording, it holds either an event envelope or a raw page of
+// chat messages.
+type recordEntry struct {
+	At    time.Time                            `json:"at"`
+	Event json.RawMessage                      `json:"event,omitempty"`
+	Page  *youtube.LiveChatMessageListResponse `json:"page,omitempty"`
+}
+
+// Recorder writes the events of an attached chat, and optionally the raw pages of chat
+// messages, to a JSONL recording that can be replayed with NewReplayer.
+type Recorder struct {
+	now func() time.Time
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{now: time.Now, enc: json.NewEncoder(w)}
+}
+
+// Record writes the event to the recording.
+func (r *Recorder) Record(event LiveEvent) error {
+	data, err := MarshalEvent(event)
+	if err != nil {
+		return err
+	}
+	return r.write(recordEntry{Event: data})
+}
+
+// RecordPage writes a raw page of chat messages to the recording, see RecordPages to
+// record the pages of an attached chat.
+func (r *Recorder) RecordPage(resp *youtube.LiveChatMessageListResponse) error {
+	return r.write(recordEntry{Page: resp})
+}
+
+func (r *Recorder) write(entry recordEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	entry.At = r.now().UTC()
+	if err := r.enc.Encode(entry); err != nil {
+		r.err = fmt.Errorf("could not write recording: %w", err)
+	}
+	return r.err
+}
+
+// Err returns the first error writing the recording, the recording stops at that error.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Tee records the events and passes them on to the returned channel, which is closed when
+// events is closed or ctx is done. Recording errors do not stop the events, check Err.
+func (r *Recorder) Tee(ctx context.Context, events <-chan LiveEvent) <-chan LiveEvent {
+	out := make(chan LiveEvent, cap(events))
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				_ = r.Record(event)
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+type ReplayOption func(r *Replayer)
+
+// ReplaySpeed sets how fast the recording is replayed compared to when it was recorded,
+// such as 10 to replay ten times faster. The default is 1, real time.
+func ReplaySpeed(factor float64) ReplayOption {
+	return func(r *Replayer) {
+		r.speed = factor
+	}
+}
+
+// ReplayInstant replays the recording without waiting between events.
+func ReplayInstant() ReplayOption {
+	return func(r *Replayer) {
+		r.speed = 0
+	}
+}
+
+// ReplayPages replays the events parsed from the recorded raw pages instead of the
+// recorded events, such as to test changes to the chat message parsing.
+func ReplayPages() ReplayOption {
+	return func(r *Replayer) {
+		r.pages = true
+	}
+}
+
+// Replayer replays a recording written by a Recorder as if it was an attached chat.
+type Replayer struct {
+	speed   float64
+	pages   bool
+	entries []recordEntry
+	log     *slog.Logger
+
+	mu   sync.Mutex
+	sent []BotEvent
+}
+
+// NewReplayer reads the recording, an error is returned when it is not a valid recording.
+func NewReplayer(recording io.Reader, options ...ReplayOption) (*Replayer, error) {
+	r := &Replayer{
+		speed: 1,
+		log:   slog.Default(),
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	scanner := bufio.NewScanner(recording)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry recordEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid recording line %d: %w", line, err)
+		}
+		if entry.Event != nil {
+			if _, err := UnmarshalEvent(entry.Event); err != nil {
+				return nil, fmt.Errorf("invalid recording line %d: %w", line, err)
+			}
+		}
+		if (r.pages && entry.Page != nil) || (!r.pages && entry.Event != nil) {
+			r.entries = append(r.entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read recording: %w", err)
+	}
+	return r, nil
+}
+
+// Attach returns the same pair of channels as YouTubeLive.Attach. The events of the
+// recording are sent in order with the recorded timing adjusted by the ReplaySpeed and the
+// events channel is closed after the last one. The BotEvents written are not sent
+// anywhere, they are kept for Sent. Attach can be called again to replay from the start.
+func (r *Replayer) Attach(ctx context.Context) (<-chan LiveEvent, chan<- BotEvent) {
+	outChan := make(chan LiveEvent, 100)
+	inChan := make(chan BotEvent, 100)
+
+	go func() {
+		defer close(outChan)
+		r.replay(ctx, outChan)
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-inChan:
+				if !ok {
+					return
+				}
+				r.mu.Lock()
+				r.sent = append(r.sent, evt)
+				r.mu.Unlock()
+			}
+		}
+	}()
+
+	return outChan, inChan
+}
+
+// Sent returns the BotEvents written to the channels returned by Attach.
+func (r *Replayer) Sent() []BotEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]BotEvent(nil), r.sent...)
+}
+
+func (r *Replayer) replay(ctx context.Context, out chan<- LiveEvent) {
+	for i, entry := range r.entries {
+		if i > 0 && r.speed > 0 {
+			delay := time.Duration(float64(entry.At.Sub(r.entries[i-1].At)) / r.speed)
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+		}
+
+		var events []LiveEvent
+		if entry.Page != nil {
+			for _, msg := range entry.Page.Items {
+				event, err := parseChatMessage(r.log, msg, entry.Page.NextPageToken)
+				if err != nil {
+					r.log.Warn("failed to parse recorded chat message", "error", err)
+					continue
+				}
+				events = append(events, event)
+			}
+		} else {
+			// Validated by NewReplayer.
+			event, _ := UnmarshalEvent(entry.Event)
+			events = append(events, event)
+		}
+
+		for _, event := range events {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package youtubelive
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collect(events <-chan LiveEvent) []LiveEvent {
+	var got []LiveEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	return got
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	var recording bytes.Buffer
+	recorder := NewRecorder(&recording)
+
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake, RecordPages(recorder))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	attached, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	events := recorder.Tee(ctx, attached)
+	fake.queue(textMessage("1", "viewer", "hello"), textMessage("2", "viewer", "world"))
+	recorded := []LiveEvent{nextChatMessage(t, events), nextChatMessage(t, events)}
+	cancel()
+	for range events {
+	}
+	assert.NoError(t, recorder.Err())
+
+	replayer, err := NewReplayer(bytes.NewReader(recording.Bytes()), ReplayInstant())
+	if !assert.NoError(t, err) {
+		return
+	}
+	replayed, commands := replayer.Attach(context.Background())
+	commands <- BotChatMessage{Message: "hi"}
+	assert.Equal(t, recorded, collect(replayed))
+	assert.Eventually(t, func() bool {
+		return len(replayer.Sent()) == 1
+	}, time.Second, time.Millisecond)
+
+	replayer, err = NewReplayer(bytes.NewReader(recording.Bytes()), ReplayInstant(), ReplayPages())
+	if !assert.NoError(t, err) {
+		return
+	}
+	fromPages, _ := replayer.Attach(context.Background())
+	assert.Equal(t, recorded, collect(fromPages))
+}
+
+func TestReplayer_Pacing(t *testing.T) {
+	var recording bytes.Buffer
+	recorder := NewRecorder(&recording)
+	now := time.Unix(0, 0)
+	recorder.now = func() time.Time { return now }
+	assert.NoError(t, recorder.Record(chatFrom(AuthorDetails{}, "first")))
+	now = now.Add(2 * time.Second)
+	assert.NoError(t, recorder.Record(chatFrom(AuthorDetails{}, "second")))
+
+	replayer, err := NewReplayer(bytes.NewReader(recording.Bytes()), ReplaySpeed(20))
+	if !assert.NoError(t, err) {
+		return
+	}
+	start := time.Now()
+	events, _ := replayer.Attach(context.Background())
+	assert.Len(t, collect(events), 2)
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 100*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+
+	_, err = NewReplayer(bytes.NewBufferString("{\"at\":\"2025-01-01T00:00:00Z\",\"event\":{\"version\":9}}\n"))
+	assert.ErrorIs(t, err, ErrUnsupportedEnvelope)
+}
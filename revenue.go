@@ -0,0 +1,321 @@
+package youtubelive
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ExchangeRates converts amounts between currencies for the normalized total of Revenue.
+// RateTable is a static implementation, plug in another one for live rates.
+type ExchangeRates interface {
+	// Convert converts the amount in micros of the from currency to micros of the to
+	// currency. It returns an error wrapping ErrUnknownCurrency when there is no rate.
+	Convert(micros int64, from, to string) (int64, error)
+}
+
+// RateTable is a static table of exchange rates relative to a base currency. Conversions
+// are exact up to rounding the result to the nearest micro.
+type RateTable struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// NewRateTable returns a table with the rates of the currencies, each rate is the amount of
+// the currency that one unit of the base currency buys, such as "0.92" for EUR with the
+// base USD. Rates are decimal strings so they are used exactly as written.
+func NewRateTable(base string, rates map[string]string) (*RateTable, error) {
+	table := &RateTable{base: strings.ToUpper(base), rates: make(map[string]*big.Rat, len(rates))}
+	for currency, rate := range rates {
+		r, ok := new(big.Rat).SetString(rate)
+		if !ok || r.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: %s rate %q", ErrInvalidExchangeRate, currency, rate)
+		}
+		table.rates[strings.ToUpper(currency)] = r
+	}
+	return table, nil
+}
+
+// ParseRateTable reads a rate table in JSON, such as
+//
+//	{"base": "USD", "rates": {"EUR": 0.92, "JPY": "151.3"}}
+//
+// see NewRateTable.
+func ParseRateTable(r io.Reader) (*RateTable, error) {
+	var file struct {
+		Base  string                 `json:"base"`
+		Rates map[string]json.Number `json:"rates"`
+	}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("could not read rate table: %w", err)
+	}
+	rates := make(map[string]string, len(file.Rates))
+	for currency, rate := range file.Rates {
+		rates[currency] = rate.String()
+	}
+	return NewRateTable(file.Base, rates)
+}
+
+// LoadRateTable reads a rate table file, see ParseRateTable.
+func LoadRateTable(path string) (*RateTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRateTable(f)
+}
+
+//go:embed exchangerates.json
+var defaultExchangeRates []byte
+
+// DefaultRateTable returns the rate table shipped with the library, the rates of the
+// currencies of Super Chats against USD at the date in exchangerates.json. The rates are
+// not updated, load a current table with LoadRateTable for exact totals.
+func DefaultRateTable() *RateTable {
+	return defaultRateTable()
+}
+
+var defaultRateTable = sync.OnceValue(func() *RateTable {
+	table, err := ParseRateTable(bytes.NewReader(defaultExchangeRates))
+	if err != nil {
+		panic("youtubelive: invalid exchangerates.json: " + err.Error())
+	}
+	return table
+})
+
+func (t *RateTable) rate(currency string) (*big.Rat, error) {
+	currency = strings.ToUpper(currency)
+	if currency == t.base {
+		return big.NewRat(1, 1), nil
+	}
+	if r, ok := t.rates[currency]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+}
+
+func (t *RateTable) Convert(micros int64, from, to string) (int64, error) {
+	if strings.EqualFold(from, to) {
+		return micros, nil
+	}
+	fromRate, err := t.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := t.rate(to)
+	if err != nil {
+		return 0, err
+	}
+	amount := new(big.Rat).SetInt64(micros)
+	amount.Mul(amount, toRate)
+	amount.Quo(amount, fromRate)
+	return roundRat(amount), nil
+}
+
+// roundRat rounds to the nearest integer, halves away from zero.
+func roundRat(r *big.Rat) int64 {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(r.Sign())))
+	}
+	return quo.Int64()
+}
+
+// StreamRevenue is the revenue of a stream collected by Revenue. Amounts are in micros by
+// currency code.
+type StreamRevenue struct {
+	BroadcastID        string           `json:"broadcastId"`
+	SuperChats         int              `json:"superChats"`
+	SuperChatMicros    map[string]int64 `json:"superChatMicros,omitempty"`
+	SuperStickers      int              `json:"superStickers"`
+	SuperStickerMicros map[string]int64 `json:"superStickerMicros,omitempty"`
+	// GiftedMemberships counts the memberships gifted, GiftsByTier counts them by tier.
+	GiftedMemberships int            `json:"giftedMemberships"`
+	GiftsByTier       map[string]int `json:"giftsByTier,omitempty"`
+	// GiftMicros totals the gifted memberships of the tiers with a price, see
+	// GiftMembershipPrice. YouTube does not tell the price of gifted memberships.
+	GiftMicros map[string]int64 `json:"giftMicros,omitempty"`
+	// Currency and TotalMicros are the total of every amount converted to one currency, see
+	// NormalizeTo.
+	Currency    string `json:"currency,omitempty"`
+	TotalMicros int64  `json:"totalMicros,omitempty"`
+}
+
+type giftPrice struct {
+	currency string
+	micros   int64
+}
+
+// Revenue totals the Super Chats, Super Stickers and gifted memberships of streams, see
+// Record and Middleware.
+type Revenue struct {
+	mu         sync.Mutex
+	streams    map[string]*StreamRevenue
+	order      []string
+	currency   string
+	rates      ExchangeRates
+	giftPrices map[string]giftPrice
+}
+
+type RevenueOption func(r *Revenue)
+
+// NormalizeTo totals every amount of a stream in the currency with the exchange rates, in
+// StreamRevenue.TotalMicros. Each currency is converted once from its total, so rounding
+// does not add up over many small amounts. The default is USD with DefaultRateTable, nil
+// rates leave out the total.
+func NormalizeTo(currency string, rates ExchangeRates) RevenueOption {
+	return func(r *Revenue) {
+		r.currency, r.rates = strings.ToUpper(currency), rates
+	}
+}
+
+// GiftMembershipPrice sets the price of one gifted membership of the tier, as shown by
+// MembershipGiftEvent.Tier. The blank tier sets the price of the tiers without a price of
+// their own. Gifted memberships without a price are only counted.
+func GiftMembershipPrice(tier, currency string, micros int64) RevenueOption {
+	return func(r *Revenue) {
+		r.giftPrices[tier] = giftPrice{currency: strings.ToUpper(currency), micros: micros}
+	}
+}
+
+func NewRevenue(opts ...RevenueOption) *Revenue {
+	r := &Revenue{
+		streams:    make(map[string]*StreamRevenue),
+		currency:   "USD",
+		rates:      DefaultRateTable(),
+		giftPrices: make(map[string]giftPrice),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Middleware records every event to the stream, for EventMiddleware. Use Record with the
+// BroadcastID of HubEvent for a Hub.
+func (r *Revenue) Middleware(broadcastID string) Middleware {
+	return func(event LiveEvent) []LiveEvent {
+		r.Record(broadcastID, event)
+		return []LiveEvent{event}
+	}
+}
+
+// Record adds the event to the revenue of the stream, events other than Super Chats, Super
+// Stickers and membership gifts are ignored.
+func (r *Revenue) Record(broadcastID string, event LiveEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch e := event.(type) {
+	case *SuperChatEvent:
+		stream := r.stream(broadcastID)
+		stream.SuperChats++
+		addMicros(&stream.SuperChatMicros, e.Currency, e.AmountMicros)
+	case *SuperStickerEvent:
+		stream := r.stream(broadcastID)
+		stream.SuperStickers++
+		addMicros(&stream.SuperStickerMicros, e.Currency, e.AmountMicros)
+	case *MembershipGiftEvent:
+		stream := r.stream(broadcastID)
+		stream.GiftedMemberships += e.Total
+		if stream.GiftsByTier == nil {
+			stream.GiftsByTier = make(map[string]int)
+		}
+		stream.GiftsByTier[e.Tier] += e.Total
+		price, ok := r.giftPrices[e.Tier]
+		if !ok {
+			price, ok = r.giftPrices[""]
+		}
+		if ok {
+			addMicros(&stream.GiftMicros, price.currency, price.micros*int64(e.Total))
+		}
+	}
+}
+
+func (r *Revenue) stream(broadcastID string) *StreamRevenue {
+	stream, ok := r.streams[broadcastID]
+	if !ok {
+		stream = &StreamRevenue{BroadcastID: broadcastID}
+		r.streams[broadcastID] = stream
+		r.order = append(r.order, broadcastID)
+	}
+	return stream
+}
+
+func addMicros(totals *map[string]int64, currency string, micros int64) {
+	if currency == "" {
+		return
+	}
+	if *totals == nil {
+		*totals = make(map[string]int64)
+	}
+	(*totals)[strings.ToUpper(currency)] += micros
+}
+
+// Stream returns the revenue of the stream. It returns an error wrapping
+// ErrUnknownCurrency, with the revenue without the normalized total, when the exchange
+// rates of NormalizeTo are missing a currency of the stream.
+func (r *Revenue) Stream(broadcastID string) (StreamRevenue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stream, ok := r.streams[broadcastID]
+	if !ok {
+		return StreamRevenue{BroadcastID: broadcastID, Currency: r.currency}, nil
+	}
+	return r.snapshot(stream)
+}
+
+// Streams returns the revenue of every stream in the order they were first recorded. Like
+// Stream, it returns every stream along with the first error of the normalized totals.
+func (r *Revenue) Streams() ([]StreamRevenue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	streams := make([]StreamRevenue, 0, len(r.order))
+	var firstErr error
+	for _, broadcastID := range r.order {
+		stream, err := r.snapshot(r.streams[broadcastID])
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		streams = append(streams, stream)
+	}
+	return streams, firstErr
+}
+
+func (r *Revenue) snapshot(stream *StreamRevenue) (StreamRevenue, error) {
+	s := *stream
+	s.SuperChatMicros = maps.Clone(stream.SuperChatMicros)
+	s.SuperStickerMicros = maps.Clone(stream.SuperStickerMicros)
+	s.GiftsByTier = maps.Clone(stream.GiftsByTier)
+	s.GiftMicros = maps.Clone(stream.GiftMicros)
+	if r.rates == nil {
+		return s, nil
+	}
+
+	byCurrency := make(map[string]int64)
+	for _, totals := range []map[string]int64{s.SuperChatMicros, s.SuperStickerMicros, s.GiftMicros} {
+		for currency, micros := range totals {
+			byCurrency[currency] += micros
+		}
+	}
+	var total int64
+	for _, currency := range slices.Sorted(maps.Keys(byCurrency)) {
+		micros, err := r.rates.Convert(byCurrency[currency], currency, r.currency)
+		if err != nil {
+			return s, err
+		}
+		total += micros
+	}
+	s.Currency, s.TotalMicros = r.currency, total
+	return s, nil
+}
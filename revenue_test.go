@@ -0,0 +1,114 @@
+package youtubelive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateTable_Convert(t *testing.T) {
+	rates, err := ParseRateTable(strings.NewReader(`{"base": "USD", "rates": {"EUR": 0.92, "jpy": "151.3", "GBP": "0.79"}}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	tests := []struct {
+		micros   int64
+		from, to string
+		want     int64
+	}{
+		{1_000_000, "USD", "USD", 1_000_000},
+		{1_000_000, "USD", "EUR", 920_000},
+		{920_000, "EUR", "USD", 1_000_000},
+		{151_300_000, "JPY", "usd", 1_000_000},
+		{100, "JPY", "EUR", 1},
+		{1, "USD", "EUR", 1},
+		{10_000_000, "GBP", "EUR", 11_645_570},
+	}
+	for _, tt := range tests {
+		got, err := rates.Convert(tt.micros, tt.from, tt.to)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%d %s to %s", tt.micros, tt.from, tt.to)
+	}
+
+	_, err = rates.Convert(1, "CAD", "USD")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+	_, err = NewRateTable("USD", map[string]string{"EUR": "0"})
+	assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+}
+
+func TestLoadRateTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"base": "EUR", "rates": {"USD": "1.25"}}`), 0o600))
+	rates, err := LoadRateTable(path)
+	if assert.NoError(t, err) {
+		got, err := rates.Convert(5_000_000, "USD", "EUR")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4_000_000), got)
+	}
+}
+
+func TestRevenue_DefaultRates(t *testing.T) {
+	revenue := NewRevenue()
+	revenue.Record("stream", &SuperChatEvent{AmountMicros: 1_000_000, Currency: "USD"})
+	revenue.Record("stream", &SuperChatEvent{AmountMicros: 157_200_000, Currency: "JPY"})
+	stream, err := revenue.Stream("stream")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", stream.Currency)
+	assert.Equal(t, int64(2_000_000), stream.TotalMicros)
+
+	stream, err = NewRevenue(NormalizeTo("", nil)).Stream("stream")
+	assert.NoError(t, err)
+	assert.Empty(t, stream.Currency)
+}
+
+func TestRevenue(t *testing.T) {
+	rates, err := NewRateTable("USD", map[string]string{"EUR": "0.8"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	revenue := NewRevenue(
+		NormalizeTo("USD", rates),
+		GiftMembershipPrice("", "USD", 4_990_000),
+		GiftMembershipPrice("Gold", "EUR", 8_000_000),
+	)
+	middleware := revenue.Middleware("stream-1")
+	for _, event := range []LiveEvent{
+		&SuperChatEvent{AmountMicros: 1_000_000, Currency: "USD"},
+		&SuperChatEvent{AmountMicros: 100_000, Currency: "USD"},
+		&SuperChatEvent{AmountMicros: 2_000_000, Currency: "EUR"},
+		&SuperStickerEvent{AmountMicros: 200_000, Currency: "USD"},
+		&MembershipGiftEvent{Total: 5, Tier: "Silver"},
+		&MembershipGiftEvent{Total: 1, Tier: "Gold"},
+		&ChatMessageEvent{Message: "hi"},
+	} {
+		assert.Len(t, middleware(event), 1)
+	}
+	revenue.Record("stream-2", &ChatMessageEvent{Message: "not revenue"})
+	revenue.Record("stream-2", &SuperChatEvent{AmountMicros: 1_000_000, Currency: "CAD"})
+
+	stream, err := revenue.Stream("stream-1")
+	assert.NoError(t, err)
+	assert.Equal(t, StreamRevenue{
+		BroadcastID:        "stream-1",
+		SuperChats:         3,
+		SuperChatMicros:    map[string]int64{"USD": 1_100_000, "EUR": 2_000_000},
+		SuperStickers:      1,
+		SuperStickerMicros: map[string]int64{"USD": 200_000},
+		GiftedMemberships:  6,
+		GiftsByTier:        map[string]int{"Silver": 5, "Gold": 1},
+		GiftMicros:         map[string]int64{"USD": 24_950_000, "EUR": 8_000_000},
+		Currency:           "USD",
+		TotalMicros:        1_100_000 + 200_000 + 24_950_000 + (2_000_000+8_000_000)*10/8,
+	}, stream)
+
+	streams, err := revenue.Streams()
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+	if assert.Len(t, streams, 2) {
+		assert.Equal(t, "stream-1", streams[0].BroadcastID)
+		assert.Equal(t, map[string]int64{"CAD": 1_000_000}, streams[1].SuperChatMicros)
+		assert.Empty(t, streams[1].Currency)
+	}
+}
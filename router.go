@@ -0,0 +1,309 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Permission is the level an author needs to run a command, it is derived from the
+// AuthorDetails of the chat message.
+type Permission int
+
+const (
+	PermissionEveryone Permission = iota
+	PermissionMember
+	PermissionModerator
+	PermissionOwner
+)
+
+func (p Permission) String() string {
+	switch p {
+	case PermissionEveryone:
+		return "everyone"
+	case PermissionMember:
+		return "member"
+	case PermissionModerator:
+		return "moderator"
+	case PermissionOwner:
+		return "owner"
+	}
+	return fmt.Sprintf("permission(%d)", int(p))
+}
+
+// PermissionOf returns the highest permission of the author.
+func PermissionOf(author AuthorDetails) Permission {
+	switch {
+	case author.IsChatOwner:
+		return PermissionOwner
+	case author.IsChatModerator:
+		return PermissionModerator
+	case author.IsChatSponsor:
+		return PermissionMember
+	}
+	return PermissionEveryone
+}
+
+// CommandHandler handles a command, a returned error is logged by the Router.
+type CommandHandler func(c *CommandContext) error
+
+// Command is a chat command registered with Router.Handle.
+type Command struct {
+	// Name is matched case-insensitively after the router prefix, such as "so" for "!so".
+	Name    string
+	Aliases []string
+	// Permission is the lowest permission allowed to run the command.
+	Permission Permission
+	// Cooldown is the time after the command is run before anyone can run it again.
+	Cooldown time.Duration
+	// UserCooldown is the time after the command is run before the same author can run it
+	// again.
+	UserCooldown time.Duration
+	Handler      CommandHandler
+}
+
+// CommandContext is passed to a CommandHandler for every matched chat message.
+type CommandContext struct {
+	context.Context
+	// Command is the name the command was registered with, even when an alias was used.
+	Command string
+	// Args are the whitespace separated arguments, double-quoted arguments may contain
+	// whitespace.
+	Args []string
+	// RawArgs is the text after the command name.
+	RawArgs string
+	Event   *ChatMessageEvent
+
+	commands chan<- BotEvent
+}
+
+// Reply sends a chat message to the live chat.
+func (c *CommandContext) Reply(message string) error {
+	return c.send(BotChatMessage{Message: message, ReplyTo: c.Event.MessageID})
+}
+
+// Delete deletes the chat message that triggered the command.
+func (c *CommandContext) Delete() error {
+	return c.send(BotDeleteMessage{MessageID: c.Event.MessageID})
+}
+
+func (c *CommandContext) send(event BotEvent) error {
+	select {
+	case c.commands <- event:
+		return nil
+	case <-c.Done():
+		return c.Err()
+	}
+}
+
+type RouterOption func(r *Router)
+
+// RouterPrefix sets the prefix commands start with, the default is "!".
+func RouterPrefix(prefix string) RouterOption {
+	return func(r *Router) {
+		r.prefix = prefix
+	}
+}
+
+// RouterLogger sets the logger used for failed commands, the default is slog.Default().
+func RouterLogger(log *slog.Logger) RouterOption {
+	return func(r *Router) {
+		r.log = log
+	}
+}
+
+// CooldownExempt sets the permission that is not subject to cooldowns, the default is
+// PermissionModerator. Commands run by exempt authors do not start the cooldowns either.
+func CooldownExempt(permission Permission) RouterOption {
+	return func(r *Router) {
+		r.cooldownExempt = permission
+	}
+}
+
+// NotCommand sets a function called for every event that is not a command, by default
+// those events are dropped.
+func NotCommand(handler func(event LiveEvent)) RouterOption {
+	return func(r *Router) {
+		r.notCommand = handler
+	}
+}
+
+// Router runs the registered commands for the chat messages received from Attach.
+type Router struct {
+	prefix         string
+	log            *slog.Logger
+	cooldownExempt Permission
+	notCommand     func(event LiveEvent)
+	now            func() time.Time
+
+	mu       sync.Mutex
+	commands map[string]*Command
+	lastUsed map[string]time.Time
+	userUsed map[userCommand]time.Time
+}
+
+type userCommand struct {
+	command   string
+	channelID string
+}
+
+func NewRouter(options ...RouterOption) *Router {
+	r := &Router{
+		prefix:         "!",
+		log:            slog.Default(),
+		cooldownExempt: PermissionModerator,
+		now:            time.Now,
+		commands:       make(map[string]*Command),
+		lastUsed:       make(map[string]time.Time),
+		userUsed:       make(map[userCommand]time.Time),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Handle registers the command under its name and aliases. It returns ErrInvalidCommand
+// for a command without a name or handler.
+func (r *Router) Handle(command Command) error {
+	if command.Name == "" || command.Handler == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidCommand, command.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := append([]string{command.Name}, command.Aliases...)
+	for _, name := range names {
+		if _, ok := r.commands[strings.ToLower(name)]; ok {
+			return fmt.Errorf("%w: %s", ErrCommandExists, name)
+		}
+	}
+	for _, name := range names {
+		r.commands[strings.ToLower(name)] = &command
+	}
+	return nil
+}
+
+// Run handles the events until the events channel is closed or ctx is done. Replies are
+// written to commands, which is the BotEvent channel returned by Attach.
+func (r *Router) Run(ctx context.Context, events <-chan LiveEvent, commands chan<- BotEvent) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			r.HandleEvent(ctx, event, commands)
+		}
+	}
+}
+
+// HandleEvent runs the command of a single event, it can be used instead of Run when the
+// events are consumed elsewhere. It reports whether a command was run.
+func (r *Router) HandleEvent(ctx context.Context, event LiveEvent, commands chan<- BotEvent) bool {
+	msg, ok := event.(*ChatMessageEvent)
+	if !ok || !strings.HasPrefix(msg.Message, r.prefix) {
+		r.forward(event)
+		return false
+	}
+	name, rawArgs := cutSpace(strings.TrimPrefix(msg.Message, r.prefix))
+	command, ok := r.allow(strings.ToLower(name), msg.AuthorDetails)
+	if !ok {
+		r.forward(event)
+		return false
+	}
+
+	c := &CommandContext{
+		Context:  ctx,
+		Command:  command.Name,
+		Args:     splitArgs(rawArgs),
+		RawArgs:  strings.TrimSpace(rawArgs),
+		Event:    msg,
+		commands: commands,
+	}
+	if err := command.Handler(c); err != nil {
+		r.log.Warn("command failed", "command", command.Name, "author", msg.AuthorDetails.ChannelId, "error", err)
+	}
+	return true
+}
+
+func (r *Router) forward(event LiveEvent) {
+	if r.notCommand != nil {
+		r.notCommand(event)
+	}
+}
+
+// allow returns the command when the author may run it now and records its use, the use
+// of authors exempt from cooldowns is not recorded.
+func (r *Router) allow(name string, author AuthorDetails) (*Command, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	command, ok := r.commands[name]
+	if !ok {
+		return nil, false
+	}
+	permission := PermissionOf(author)
+	if permission < command.Permission {
+		r.log.Debug("command not permitted", "command", command.Name, "author", author.ChannelId, "permission", permission)
+		return nil, false
+	}
+
+	now := r.now()
+	user := userCommand{command: command.Name, channelID: author.ChannelId}
+	if permission < r.cooldownExempt {
+		if now.Sub(r.lastUsed[command.Name]) < command.Cooldown ||
+			now.Sub(r.userUsed[user]) < command.UserCooldown {
+			r.log.Debug("command on cooldown", "command", command.Name, "author", author.ChannelId)
+			return nil, false
+		}
+		r.lastUsed[command.Name] = now
+		r.userUsed[user] = now
+	}
+	return command, true
+}
+
+// cutSpace slices s around the first whitespace, the whitespace is dropped.
+func cutSpace(s string) (before, after string) {
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return s[:i], s[i+size:]
+}
+
+// splitArgs splits on whitespace keeping double-quoted text together.
+func splitArgs(s string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+		inArg   bool
+	)
+	for _, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inArg = true
+		case unicode.IsSpace(c) && !quoted:
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
@@ -0,0 +1,106 @@
+package youtubelive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func chatFrom(author AuthorDetails, message string) *ChatMessageEvent {
+	return &ChatMessageEvent{MessageID: "id-" + message, Message: message, DisplayName: author.DisplayName, AuthorDetails: author}
+}
+
+func TestRouter_Commands(t *testing.T) {
+	viewer := AuthorDetails{ChannelId: "UCviewer", DisplayName: "viewer"}
+	moderator := AuthorDetails{ChannelId: "UCmod", DisplayName: "mod", IsChatModerator: true}
+
+	now := time.Unix(0, 0)
+	var notCommands []LiveEvent
+	r := NewRouter(NotCommand(func(event LiveEvent) {
+		notCommands = append(notCommands, event)
+	}))
+	r.now = func() time.Time { return now }
+
+	var args [][]string
+	assert.NoError(t, r.Handle(Command{
+		Name:         "so",
+		Aliases:      []string{"shoutout"},
+		UserCooldown: time.Minute,
+		Handler: func(c *CommandContext) error {
+			args = append(args, c.Args)
+			return c.Reply("check out " + c.Args[0])
+		},
+	}))
+	assert.NoError(t, r.Handle(Command{
+		Name:       "clear",
+		Permission: PermissionModerator,
+		Handler: func(c *CommandContext) error {
+			return c.Delete()
+		},
+	}))
+	noop := func(*CommandContext) error { return nil }
+	assert.ErrorIs(t, r.Handle(Command{Name: "SO", Handler: noop}), ErrCommandExists)
+	assert.ErrorIs(t, r.Handle(Command{Name: "nohandler"}), ErrInvalidCommand)
+	assert.ErrorIs(t, r.Handle(Command{Handler: noop}), ErrInvalidCommand)
+
+	events := make(chan LiveEvent, 10)
+	commands := make(chan BotEvent, 10)
+	events <- chatFrom(viewer, `!so "some one" extra`)
+	events <- chatFrom(viewer, "!shoutout again") // user cooldown
+	events <- chatFrom(viewer, "!clear")          // not permitted
+	events <- chatFrom(moderator, "!clear")
+	events <- chatFrom(viewer, "hello")
+	close(events)
+	assert.NoError(t, r.Run(context.Background(), events, commands))
+	close(commands)
+
+	var sent []BotEvent
+	for c := range commands {
+		sent = append(sent, c)
+	}
+	assert.Equal(t, []BotEvent{
+		BotChatMessage{Message: "check out some one", ReplyTo: `id-!so "some one" extra`},
+		BotDeleteMessage{MessageID: "id-!clear"},
+	}, sent)
+	assert.Equal(t, [][]string{{"some one", "extra"}}, args)
+	assert.Len(t, notCommands, 3)
+
+	now = now.Add(time.Minute)
+	assert.True(t, r.HandleEvent(context.Background(), chatFrom(viewer, "!SO later"), make(chan BotEvent, 1)))
+	now = now.Add(time.Minute)
+	assert.True(t, r.HandleEvent(context.Background(), chatFrom(viewer, "!so\u3000tabbed\tname"), make(chan BotEvent, 1)))
+	assert.Equal(t, []string{"tabbed", "name"}, args[len(args)-1], "commands split on any whitespace")
+}
+
+func TestRouter_Cooldown(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := NewRouter(RouterPrefix("?"))
+	r.now = func() time.Time { return now }
+	runs := 0
+	assert.NoError(t, r.Handle(Command{
+		Name:     "hype",
+		Cooldown: 30 * time.Second,
+		Handler: func(c *CommandContext) error {
+			runs++
+			return errors.New("logged, not fatal")
+		},
+	}))
+
+	commands := make(chan BotEvent, 1)
+	ctx := context.Background()
+	assert.True(t, r.HandleEvent(ctx, chatFrom(AuthorDetails{ChannelId: "a"}, "?hype"), commands))
+	assert.False(t, r.HandleEvent(ctx, chatFrom(AuthorDetails{ChannelId: "b"}, "?hype"), commands))
+	assert.True(t, r.HandleEvent(ctx, chatFrom(AuthorDetails{ChannelId: "c", IsChatOwner: true}, "?hype"), commands))
+	now = now.Add(30 * time.Second)
+	assert.True(t, r.HandleEvent(ctx, chatFrom(AuthorDetails{ChannelId: "c", IsChatOwner: true}, "?hype"), commands))
+	assert.True(t, r.HandleEvent(ctx, chatFrom(AuthorDetails{ChannelId: "b"}, "?hype"), commands), "exempt authors do not start the cooldown")
+	assert.Equal(t, 4, runs)
+}
+
+func TestSplitArgs(t *testing.T) {
+	assert.Equal(t, []string{"a", "b c", "", "d"}, splitArgs(` a  "b c" "" d `))
+	assert.Nil(t, splitArgs("   "))
+}
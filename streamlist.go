@@ -0,0 +1,558 @@
+package youtubelive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	defaultStreamListAddr = "youtube.googleapis.com:443"
+	streamListMethod      = "/youtube.api.v3.V3DataLiveChatMessageService/StreamList"
+	// maxStreamListFailures is how many times in a row the stream may fail to deliver a
+	// response before falling back to polling.
+	maxStreamListFailures = 3
+)
+
+var streamListDesc = &grpc.StreamDesc{
+	StreamName:    "StreamList",
+	ServerStreams: true,
+}
+
+// streamLiveChat receives the live chat through the server streaming
+// liveChatMessages.streamList endpoint and sends the events to out. It returns the page
+// token to continue from and whether the chat is done, false means the stream is not
+// usable and polling should take over.
+func (yt *YouTubeLive) streamLiveChat(ctx context.Context, liveChatID string, out chan<- LiveEvent) (string, bool) {
+	dialOptions := yt.streamListDialOptions
+	if len(dialOptions) == 0 {
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(nil))}
+	}
+	conn, err := grpc.NewClient(yt.streamListAddr, dialOptions...)
+	if err != nil {
+		yt.log.Warn("live chat stream unavailable, falling back to polling", "error", err)
+		return "", false
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var (
+		pageToken string
+		failures  int
+	)
+	for {
+		received, ended, err := yt.receiveStreamList(ctx, conn, liveChatID, &pageToken, out)
+		if ended || ctx.Err() != nil {
+			return pageToken, true
+		}
+		if received {
+			failures = 0
+		}
+		failures++
+		code := status.Code(err)
+		if errors.Is(err, io.EOF) {
+			code = codes.Unavailable
+		}
+		if code != codes.Unavailable || failures >= maxStreamListFailures {
+			yt.log.Warn("live chat stream failed, falling back to polling", "error", err)
+			return pageToken, false
+		}
+		yt.log.Debug("live chat stream interrupted, reconnecting", "error", err)
+		select {
+		case <-ctx.Done():
+			return pageToken, true
+		case <-time.After(time.Duration(failures) * time.Second):
+		}
+	}
+}
+
+// receiveStreamList opens a stream starting at pageToken and delivers every response
+// until the stream fails. pageToken is updated as responses are received.
+func (yt *YouTubeLive) receiveStreamList(ctx context.Context, conn *grpc.ClientConn, liveChatID string, pageToken *string, out chan<- LiveEvent) (received bool, ended bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx, err = yt.streamListAuth(ctx)
+	if err != nil {
+		return false, false, status.Error(codes.Unauthenticated, err.Error())
+	}
+	// A stream that delivered responses used quota however it ended.
+	defer func() {
+		yt.metrics.observeCall("liveChatMessages.streamList", err, received || answered(err))
+	}()
+
+	stream, err := conn.NewStream(ctx, streamListDesc, streamListMethod, grpc.ForceCodec(streamListCodec{}))
+	if err != nil {
+		return false, false, err
+	}
+	req := &streamListRequest{
+		LiveChatID: liveChatID,
+		PageToken:  *pageToken,
+		Parts:      []string{"snippet", "authorDetails"},
+	}
+	if err = stream.SendMsg(req); err != nil {
+		return false, false, err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return false, false, err
+	}
+
+	for {
+		resp := &youtube.LiveChatMessageListResponse{}
+		if err = stream.RecvMsg(resp); err != nil {
+			return received, false, err
+		}
+		received = true
+		pageCtx, span := yt.startLinked(ctx, "youtubelive.streamList",
+			AttrLiveChatID.String(liveChatID),
+			AttrPageToken.String(*pageToken),
+			AttrItemCount.Int(len(resp.Items)))
+		if resp.NextPageToken != "" {
+			*pageToken = resp.NextPageToken
+		}
+		ended := yt.deliverMessages(pageCtx, liveChatID, resp, out)
+		span.End()
+		if ended {
+			return received, true, nil
+		}
+	}
+}
+
+// streamListAuth adds the credentials for the stream to the outgoing metadata, an API key
+// of the ReadCredentials is preferred like for other read only requests. The APIKey is
+// only used when no user is logged in, like when polling.
+func (yt *YouTubeLive) streamListAuth(ctx context.Context) (context.Context, error) {
+	if yt.pool != nil {
+		if key := yt.pool.apiKey(); key != "" {
+			return metadata.AppendToOutgoingContext(ctx, "x-goog-api-key", key), nil
+		}
+	}
+	client := yt.client()
+	if yt.keyPool != nil && !client.canLogIn() {
+		if key := yt.keyPool.apiKey(); key != "" {
+			return metadata.AppendToOutgoingContext(ctx, "x-goog-api-key", key), nil
+		}
+	}
+	token, err := client.Token()
+	if err != nil {
+		return ctx, wrapAPIError("", err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.AccessToken), nil
+}
+
+// streamListRequest is the LiveChatMessageListRequest sent to start a stream.
+type streamListRequest struct {
+	LiveChatID string
+	PageToken  string
+	Parts      []string
+}
+
+// Field numbers of the stream_list.proto messages, only the fields used by the events
+// are decoded.
+const (
+	reqLiveChatIDField = 1
+	reqPageTokenField  = 99
+	reqPartField       = 100
+
+	respNextPageTokenField = 100602380
+	respItemsField         = 1007
+	respOfflineAtField     = 2
+
+	msgIDField            = 101
+	msgSnippetField       = 2
+	msgAuthorDetailsField = 3
+
+	authorChannelIDField       = 10101
+	authorChannelURLField      = 102
+	authorDisplayNameField     = 103
+	authorProfileImageURLField = 104
+	authorIsVerifiedField      = 4
+	authorIsChatOwnerField     = 5
+	authorIsChatSponsorField   = 6
+	authorIsChatModeratorField = 7
+
+	snippetTypeField                   = 1
+	snippetLiveChatIDField             = 201
+	snippetAuthorChannelIDField        = 301
+	snippetPublishedAtField            = 4
+	snippetDisplayMessageField         = 16
+	snippetTextMessageDetailsField     = 19
+	snippetMessageDeletedDetailsField  = 20
+	snippetMessageRetractedField       = 21
+	snippetUserBannedDetailsField      = 22
+	snippetSuperChatDetailsField       = 27
+	snippetSuperStickerDetailsField    = 28
+	snippetNewSponsorDetailsField      = 29
+	snippetMemberMilestoneDetailsField = 30
+	snippetMembershipGiftingField      = 31
+	snippetGiftMembershipReceivedField = 32
+	snippetPollDetailsField            = 33
+)
+
+// streamListTypes maps the snippet type enum to the type names used by the REST API. The
+// enum numbers have gaps, unknown numbers are decoded as "unknown(n)".
+var streamListTypes = map[uint64]string{
+	0:  "invalidType",
+	1:  "textMessageEvent",
+	2:  "tombstone",
+	3:  "fanFundingEvent",
+	4:  "chatEndedEvent",
+	5:  "sponsorOnlyModeStartedEvent",
+	6:  "sponsorOnlyModeEndedEvent",
+	7:  "newSponsorEvent",
+	8:  "messageDeletedEvent",
+	9:  "messageRetractedEvent",
+	10: "userBannedEvent",
+	15: "superChatEvent",
+	16: "superStickerEvent",
+	17: "memberMilestoneChatEvent",
+	18: "membershipGiftingEvent",
+	19: "giftMembershipReceivedEvent",
+	20: "pollEvent",
+}
+
+var streamListBanTypes = map[uint64]string{1: "PERMANENT", 2: "TEMPORARY"}
+
+var streamListPollStatuses = map[uint64]string{0: "unknown", 1: "active", 2: "closed"}
+
+// streamListCodec encodes requests and decodes responses of liveChatMessages.streamList
+// directly into the REST API types so both transports share the same parsing.
+type streamListCodec struct{}
+
+func (streamListCodec) Name() string {
+	return "proto"
+}
+
+func (streamListCodec) Marshal(v any) ([]byte, error) {
+	req, ok := v.(*streamListRequest)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal %T", v)
+	}
+	var b []byte
+	b = appendString(b, reqLiveChatIDField, req.LiveChatID)
+	b = appendString(b, reqPageTokenField, req.PageToken)
+	for _, part := range req.Parts {
+		b = appendString(b, reqPartField, part)
+	}
+	return b, nil
+}
+
+func (streamListCodec) Unmarshal(data []byte, v any) error {
+	resp, ok := v.(*youtube.LiveChatMessageListResponse)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal into %T", v)
+	}
+	return decodeFields(data, func(f wireField) error {
+		switch f.num {
+		case respNextPageTokenField:
+			resp.NextPageToken = string(f.bytes)
+		case respOfflineAtField:
+			resp.OfflineAt = string(f.bytes)
+		case respItemsField:
+			msg, err := decodeLiveChatMessage(f.bytes)
+			if err != nil {
+				return err
+			}
+			resp.Items = append(resp.Items, msg)
+		}
+		return nil
+	})
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+type wireField struct {
+	num    protowire.Number
+	varint uint64
+	bytes  []byte
+}
+
+// decodeFields calls fn for every varint and length delimited field in b, other wire
+// types are skipped.
+func decodeFields(b []byte, fn func(f wireField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := wireField{num: num}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeStrings decodes a message of string fields into the targets by field number.
+func decodeStrings(b []byte, targets map[protowire.Number]*string) error {
+	return decodeFields(b, func(f wireField) error {
+		if target, ok := targets[f.num]; ok {
+			*target = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+func enumName(names map[uint64]string, v uint64) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", v)
+}
+
+func decodeLiveChatMessage(b []byte) (*youtube.LiveChatMessage, error) {
+	msg := &youtube.LiveChatMessage{}
+	err := decodeFields(b, func(f wireField) error {
+		var err error
+		switch f.num {
+		case msgIDField:
+			msg.Id = string(f.bytes)
+		case msgSnippetField:
+			msg.Snippet, err = decodeSnippet(f.bytes)
+		case msgAuthorDetailsField:
+			msg.AuthorDetails, err = decodeAuthorDetails(f.bytes)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if msg.Snippet == nil {
+		msg.Snippet = &youtube.LiveChatMessageSnippet{}
+	}
+	return msg, nil
+}
+
+func decodeAuthorDetails(b []byte) (*youtube.LiveChatMessageAuthorDetails, error) {
+	author := &youtube.LiveChatMessageAuthorDetails{}
+	err := decodeFields(b, func(f wireField) error {
+		switch f.num {
+		case authorChannelIDField:
+			author.ChannelId = string(f.bytes)
+		case authorChannelURLField:
+			author.ChannelUrl = string(f.bytes)
+		case authorDisplayNameField:
+			author.DisplayName = string(f.bytes)
+		case authorProfileImageURLField:
+			author.ProfileImageUrl = string(f.bytes)
+		case authorIsVerifiedField:
+			author.IsVerified = f.varint != 0
+		case authorIsChatOwnerField:
+			author.IsChatOwner = f.varint != 0
+		case authorIsChatSponsorField:
+			author.IsChatSponsor = f.varint != 0
+		case authorIsChatModeratorField:
+			author.IsChatModerator = f.varint != 0
+		}
+		return nil
+	})
+	return author, err
+}
+
+func decodeSnippet(b []byte) (*youtube.LiveChatMessageSnippet, error) {
+	snippet := &youtube.LiveChatMessageSnippet{}
+	err := decodeFields(b, func(f wireField) error {
+		switch f.num {
+		case snippetTypeField:
+			snippet.Type = enumName(streamListTypes, f.varint)
+		case snippetLiveChatIDField:
+			snippet.LiveChatId = string(f.bytes)
+		case snippetAuthorChannelIDField:
+			snippet.AuthorChannelId = string(f.bytes)
+		case snippetPublishedAtField:
+			snippet.PublishedAt = string(f.bytes)
+		case snippetDisplayMessageField:
+			snippet.DisplayMessage = string(f.bytes)
+		case snippetTextMessageDetailsField:
+			snippet.TextMessageDetails = &youtube.LiveChatTextMessageDetails{}
+			return decodeStrings(f.bytes, map[protowire.Number]*string{
+				1: &snippet.TextMessageDetails.MessageText,
+			})
+		case snippetMessageDeletedDetailsField:
+			snippet.MessageDeletedDetails = &youtube.LiveChatMessageDeletedDetails{}
+			return decodeStrings(f.bytes, map[protowire.Number]*string{
+				101: &snippet.MessageDeletedDetails.DeletedMessageId,
+			})
+		case snippetMessageRetractedField:
+			snippet.MessageRetractedDetails = &youtube.LiveChatMessageRetractedDetails{}
+			return decodeStrings(f.bytes, map[protowire.Number]*string{
+				201: &snippet.MessageRetractedDetails.RetractedMessageId,
+			})
+		case snippetUserBannedDetailsField:
+			details := &youtube.LiveChatUserBannedMessageDetails{}
+			snippet.UserBannedDetails = details
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					details.BannedUserDetails = &youtube.ChannelProfileDetails{}
+					return decodeStrings(f.bytes, map[protowire.Number]*string{
+						101: &details.BannedUserDetails.ChannelId,
+						102: &details.BannedUserDetails.ChannelUrl,
+						103: &details.BannedUserDetails.DisplayName,
+						104: &details.BannedUserDetails.ProfileImageUrl,
+					})
+				case 2:
+					details.BanType = enumName(streamListBanTypes, f.varint)
+				case 3:
+					details.BanDurationSeconds = f.varint
+				}
+				return nil
+			})
+		case snippetSuperChatDetailsField:
+			details := &youtube.LiveChatSuperChatDetails{}
+			snippet.SuperChatDetails = details
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					details.AmountMicros = f.varint
+				case 2:
+					details.Currency = string(f.bytes)
+				case 3:
+					details.AmountDisplayString = string(f.bytes)
+				case 4:
+					details.UserComment = string(f.bytes)
+				case 5:
+					details.Tier = int64(f.varint)
+				}
+				return nil
+			})
+		case snippetSuperStickerDetailsField:
+			details := &youtube.LiveChatSuperStickerDetails{}
+			snippet.SuperStickerDetails = details
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					details.AmountMicros = f.varint
+				case 2:
+					details.Currency = string(f.bytes)
+				case 3:
+					details.AmountDisplayString = string(f.bytes)
+				case 4:
+					details.Tier = int64(f.varint)
+				case 5:
+					details.SuperStickerMetadata = &youtube.SuperStickerMetadata{}
+					return decodeStrings(f.bytes, map[protowire.Number]*string{
+						1: &details.SuperStickerMetadata.StickerId,
+						2: &details.SuperStickerMetadata.AltText,
+						3: &details.SuperStickerMetadata.AltTextLanguage,
+					})
+				}
+				return nil
+			})
+		case snippetNewSponsorDetailsField:
+			details := &youtube.LiveChatNewSponsorDetails{}
+			snippet.NewSponsorDetails = details
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					details.MemberLevelName = string(f.bytes)
+				case 2:
+					details.IsUpgrade = f.varint != 0
+				}
+				return nil
+			})
+		case snippetMemberMilestoneDetailsField:
+			details := &youtube.LiveChatMemberMilestoneChatDetails{}
+			snippet.MemberMilestoneChatDetails = details
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					details.MemberLevelName = string(f.bytes)
+				case 2:
+					details.MemberMonth = int64(f.varint)
+				case 3:
+					details.UserComment = string(f.bytes)
+				}
+				return nil
+			})
+		case snippetMembershipGiftingField:
+			details := &youtube.LiveChatMembershipGiftingDetails{}
+			snippet.MembershipGiftingDetails = details
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					details.GiftMembershipsCount = int64(f.varint)
+				case 2:
+					details.GiftMembershipsLevelName = string(f.bytes)
+				}
+				return nil
+			})
+		case snippetGiftMembershipReceivedField:
+			snippet.GiftMembershipReceivedDetails = &youtube.LiveChatGiftMembershipReceivedDetails{}
+			return decodeStrings(f.bytes, map[protowire.Number]*string{
+				1: &snippet.GiftMembershipReceivedDetails.MemberLevelName,
+				2: &snippet.GiftMembershipReceivedDetails.GifterChannelId,
+				3: &snippet.GiftMembershipReceivedDetails.AssociatedMembershipGiftingMessageId,
+			})
+		case snippetPollDetailsField:
+			details := &youtube.LiveChatPollDetails{}
+			snippet.PollDetails = details
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					details.Metadata = &youtube.LiveChatPollDetailsPollMetadata{}
+					return decodePollMetadata(f.bytes, details.Metadata)
+				case 2:
+					details.Status = enumName(streamListPollStatuses, f.varint)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return snippet, err
+}
+
+func decodePollMetadata(b []byte, metadata *youtube.LiveChatPollDetailsPollMetadata) error {
+	return decodeFields(b, func(f wireField) error {
+		switch f.num {
+		case 1:
+			metadata.QuestionText = string(f.bytes)
+		case 2:
+			option := &youtube.LiveChatPollDetailsPollMetadataPollOption{}
+			metadata.Options = append(metadata.Options, option)
+			return decodeFields(f.bytes, func(f wireField) error {
+				switch f.num {
+				case 1:
+					option.OptionText = string(f.bytes)
+				case 2:
+					option.Tally = int64(f.varint)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
@@ -0,0 +1,254 @@
+package youtubelive
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/youtube/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fakeStreamList is a local liveChatMessages.streamList server that streams the responses
+// queued with send.
+type fakeStreamList struct {
+	addr      string
+	responses chan *youtube.LiveChatMessageListResponse
+
+	mu             sync.Mutex
+	requests       []*streamListRequest
+	authorizations []string
+}
+
+// newFakeStreamList starts a server, without registering the StreamList service when
+// register is false so clients get Unimplemented.
+func newFakeStreamList(t *testing.T, register bool) *fakeStreamList {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeStreamList{
+		addr:      listener.Addr().String(),
+		responses: make(chan *youtube.LiveChatMessageListResponse, 10),
+	}
+	srv := grpc.NewServer(grpc.ForceServerCodec(fakeStreamListCodec{}))
+	if register {
+		srv.RegisterService(&grpc.ServiceDesc{
+			ServiceName: "youtube.api.v3.V3DataLiveChatMessageService",
+			HandlerType: (*any)(nil),
+			Streams: []grpc.StreamDesc{{
+				StreamName:    "StreamList",
+				ServerStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					return f.streamList(stream)
+				},
+			}},
+		}, f)
+	}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(srv.Stop)
+	return f
+}
+
+func (f *fakeStreamList) streamList(stream grpc.ServerStream) error {
+	req := &streamListRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.authorizations = append(f.authorizations, md.Get("authorization")...)
+	f.mu.Unlock()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case resp := <-f.responses:
+			if err := stream.SendMsg(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (f *fakeStreamList) send(pageToken string, msgs ...*youtube.LiveChatMessage) {
+	f.responses <- &youtube.LiveChatMessageListResponse{NextPageToken: pageToken, Items: msgs}
+}
+
+func (f *fakeStreamList) authorizationsSeen() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.authorizations...)
+}
+
+// fakeStreamListCodec is the server side of streamListCodec.
+type fakeStreamListCodec struct{}
+
+func (fakeStreamListCodec) Name() string {
+	return "proto"
+}
+
+func (fakeStreamListCodec) Unmarshal(data []byte, v any) error {
+	req := v.(*streamListRequest)
+	return decodeFields(data, func(f wireField) error {
+		switch f.num {
+		case reqLiveChatIDField:
+			req.LiveChatID = string(f.bytes)
+		case reqPageTokenField:
+			req.PageToken = string(f.bytes)
+		case reqPartField:
+			req.Parts = append(req.Parts, string(f.bytes))
+		}
+		return nil
+	})
+}
+
+func (fakeStreamListCodec) Marshal(v any) ([]byte, error) {
+	resp, ok := v.(*youtube.LiveChatMessageListResponse)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal %T", v)
+	}
+	b := appendString(nil, respNextPageTokenField, resp.NextPageToken)
+	for _, msg := range resp.Items {
+		var snippet []byte
+		snippet = protowire.AppendTag(snippet, snippetTypeField, protowire.VarintType)
+		snippet = protowire.AppendVarint(snippet, streamListTypeNumber(msg.Snippet.Type))
+		snippet = appendString(snippet, snippetPublishedAtField, msg.Snippet.PublishedAt)
+		snippet = appendString(snippet, snippetDisplayMessageField, msg.Snippet.DisplayMessage)
+		if msg.Snippet.TextMessageDetails != nil {
+			snippet = protowire.AppendTag(snippet, snippetTextMessageDetailsField, protowire.BytesType)
+			snippet = protowire.AppendBytes(snippet, appendString(nil, 1, msg.Snippet.TextMessageDetails.MessageText))
+		}
+
+		var author []byte
+		if msg.AuthorDetails != nil {
+			author = appendString(author, authorChannelIDField, msg.AuthorDetails.ChannelId)
+			author = appendString(author, authorDisplayNameField, msg.AuthorDetails.DisplayName)
+		}
+
+		item := appendString(nil, msgIDField, msg.Id)
+		item = protowire.AppendTag(item, msgSnippetField, protowire.BytesType)
+		item = protowire.AppendBytes(item, snippet)
+		item = protowire.AppendTag(item, msgAuthorDetailsField, protowire.BytesType)
+		item = protowire.AppendBytes(item, author)
+
+		b = protowire.AppendTag(b, respItemsField, protowire.BytesType)
+		b = protowire.AppendBytes(b, item)
+	}
+	return b, nil
+}
+
+func streamListTypeNumber(name string) uint64 {
+	for num, typeName := range streamListTypes {
+		if typeName == name {
+			return num
+		}
+	}
+	return 0
+}
+
+func TestStreamListCodec_Wire(t *testing.T) {
+	tests := []struct {
+		name string
+		wire string
+		want *youtube.LiveChatMessageSnippet
+	}{
+		{"deleted", "0808", &youtube.LiveChatMessageSnippet{Type: "messageDeletedEvent"}},
+		{"banned", "080a", &youtube.LiveChatMessageSnippet{Type: "userBannedEvent"}},
+		{"sticker", "0810", &youtube.LiveChatMessageSnippet{Type: "superStickerEvent"}},
+		{"milestone", "0811", &youtube.LiveChatMessageSnippet{Type: "memberMilestoneChatEvent"}},
+		{"gap", "080c", &youtube.LiveChatMessageSnippet{Type: "unknown(12)"}},
+		{"super chat", "080f" + "da010a" + "08c096b102" + "1203555344", &youtube.LiveChatMessageSnippet{
+			Type:             "superChatEvent",
+			SuperChatDetails: &youtube.LiveChatSuperChatDetails{AmountMicros: 5_000_000, Currency: "USD"},
+		}},
+		{"poll", "0814" + "8a0212" + "0a0e" + "0a0351413f" + "12070a03796573" + "1007" + "1001", &youtube.LiveChatMessageSnippet{
+			Type: "pollEvent",
+			PollDetails: &youtube.LiveChatPollDetails{
+				Metadata: &youtube.LiveChatPollDetailsPollMetadata{
+					QuestionText: "QA?",
+					Options:      []*youtube.LiveChatPollDetailsPollMetadataPollOption{{OptionText: "yes", Tally: 7}},
+				},
+				Status: "active",
+			},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A response with one item holding the snippet.
+			snippet, err := hex.DecodeString(tt.wire)
+			if !assert.NoError(t, err) {
+				return
+			}
+			item := append([]byte{0x12, byte(len(snippet))}, snippet...)
+			data := append([]byte{0xfa, 0x3e, byte(len(item))}, item...)
+
+			resp := &youtube.LiveChatMessageListResponse{}
+			if assert.NoError(t, streamListCodec{}.Unmarshal(data, resp)) && assert.Len(t, resp.Items, 1) {
+				assert.Equal(t, tt.want, resp.Items[0].Snippet)
+			}
+		})
+	}
+}
+
+func TestYouTubeLive_AttachStreamChat(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	stream := newFakeStreamList(t, true)
+	yt := newTestYouTubeLive(t, fake, StreamChat(),
+		StreamListEndpoint(stream.addr, grpc.WithTransportCredentials(insecure.NewCredentials())))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := textMessage("1", "viewer", "hello")
+	stream.send("page-1", msg)
+	e := nextChatMessage(t, events)
+	assert.Equal(t, "1", e.MessageID)
+	assert.Equal(t, "hello", e.Message)
+	assert.Equal(t, "viewer", e.AuthorDetails.DisplayName)
+	assert.Equal(t, "page-1", e.NextPageToken)
+	assert.Equal(t, []string{"Bearer access-first"}, stream.authorizationsSeen())
+
+	stream.send("page-2", &youtube.LiveChatMessage{Snippet: &youtube.LiveChatMessageSnippet{
+		Type:        "chatEndedEvent",
+		PublishedAt: time.Now().UTC().Format(time.RFC3339),
+	}})
+	for range events {
+	}
+	assert.Empty(t, fake.authorizationsFor("/youtube/v3/liveChat/messages"), "polling should not be used")
+}
+
+func TestYouTubeLive_AttachStreamChatFallsBackToPolling(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	stream := newFakeStreamList(t, false)
+	yt := newTestYouTubeLive(t, fake, StreamChat(),
+		StreamListEndpoint(stream.addr, grpc.WithTransportCredentials(insecure.NewCredentials())))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fake.queue(textMessage("1", "viewer", "polled"))
+	assert.Equal(t, "polled", nextChatMessage(t, events).Message)
+}
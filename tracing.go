@@ -0,0 +1,99 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/steampoweredtaco/youtubelive"
+
+// maxTracedMessages is how many chat messages are remembered to link the spans of bot
+// events to the span that delivered the message they reply to.
+const maxTracedMessages = 1000
+
+// Span attributes set by the tracing, see WithTracing.
+const (
+	AttrLiveChatID   = attribute.Key("youtubelive.live_chat_id")
+	AttrBroadcastID  = attribute.Key("youtubelive.broadcast_id")
+	AttrPageToken    = attribute.Key("youtubelive.page_token")
+	AttrItemCount    = attribute.Key("youtubelive.item_count")
+	AttrPollInterval = attribute.Key("youtubelive.poll_interval_ms")
+	AttrBotEvent     = attribute.Key("youtubelive.bot_event")
+	AttrMessageID    = attribute.Key("youtubelive.message_id")
+)
+
+// messageSpans remembers the span context each chat message was delivered in.
+type messageSpans struct {
+	mu    sync.Mutex
+	spans map[string]trace.SpanContext
+	order []string
+}
+
+func newMessageSpans() *messageSpans {
+	return &messageSpans{spans: make(map[string]trace.SpanContext)}
+}
+
+func (m *messageSpans) remember(messageID string, sc trace.SpanContext) {
+	if messageID == "" || !sc.IsValid() {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.spans[messageID]; !ok {
+		m.order = append(m.order, messageID)
+	}
+	m.spans[messageID] = sc
+	if len(m.order) > maxTracedMessages {
+		delete(m.spans, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+func (m *messageSpans) links(messageID string) []trace.Link {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sc, ok := m.spans[messageID]
+	if !ok {
+		return nil
+	}
+	return []trace.Link{{SpanContext: sc, Attributes: []attribute.KeyValue{AttrMessageID.String(messageID)}}}
+}
+
+// startLinked starts a span of its own trace linked to the span in ctx, such as for every
+// poll of a chat that was attached in the span of Attach.
+func (yt *YouTubeLive) startLinked(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return yt.tracer.Start(ctx, name,
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(attrs...))
+}
+
+// botEventSpan starts the span of sending a BotEvent, linked to the span that delivered the
+// chat message it replies to or deletes.
+func (yt *YouTubeLive) botEventSpan(ctx context.Context, liveChatID string, evt BotEvent) (context.Context, trace.Span) {
+	var replyTo string
+	switch e := evt.(type) {
+	case BotChatMessage:
+		replyTo = e.ReplyTo
+	case BotDeleteMessage:
+		replyTo = e.MessageID
+	}
+	return yt.tracer.Start(ctx, "youtubelive.sendBotEvent",
+		trace.WithNewRoot(),
+		trace.WithLinks(yt.messageSpans.links(replyTo)...),
+		trace.WithAttributes(AttrLiveChatID.String(liveChatID), AttrBotEvent.String(fmt.Sprintf("%T", evt))))
+}
+
+// endSpan records err on the span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
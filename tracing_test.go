@@ -0,0 +1,79 @@
+package youtubelive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanNamed(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func hasAttribute(span tracetest.SpanStub, attr attribute.KeyValue) bool {
+	for _, a := range span.Attributes {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestYouTubeLive_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake, WithTracing(provider))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, commands, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	fake.queue(textMessage("1", "viewer", "!hello"))
+	msg := nextChatMessage(t, events)
+	commands <- BotChatMessage{Message: "hi", ReplyTo: msg.MessageID}
+
+	var send tracetest.SpanStub
+	assert.Eventually(t, func() bool {
+		var ok bool
+		send, ok = spanNamed(exporter.GetSpans(), "youtubelive.sendBotEvent")
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	spans := exporter.GetSpans()
+	attach, ok := spanNamed(spans, "youtubelive.Attach")
+	assert.True(t, ok)
+	assert.True(t, hasAttribute(attach, AttrLiveChatID.String(testLiveChatID)))
+	getLiveChatID, ok := spanNamed(spans, "youtubelive.getLiveChatID")
+	assert.True(t, ok)
+	assert.Equal(t, attach.SpanContext.SpanID(), getLiveChatID.Parent.SpanID())
+
+	var poll tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == "youtubelive.poll" && hasAttribute(span, AttrItemCount.Int(1)) {
+			poll = span
+		}
+	}
+	if assert.Len(t, poll.Links, 1, "poll of the message") {
+		assert.Equal(t, attach.SpanContext.TraceID(), poll.Links[0].SpanContext.TraceID())
+	}
+	assert.NotEqual(t, attach.SpanContext.TraceID(), poll.SpanContext.TraceID())
+	assert.True(t, hasAttribute(poll, AttrLiveChatID.String(testLiveChatID)))
+
+	if assert.Len(t, send.Links, 1) {
+		assert.Equal(t, poll.SpanContext.SpanID(), send.Links[0].SpanContext.SpanID())
+	}
+}
@@ -0,0 +1,195 @@
+package youtubelive
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ViewerStats are the statistics of a chat user collected by Viewers.
+type ViewerStats struct {
+	ChannelID       string    `json:"channelId"`
+	DisplayName     string    `json:"displayName"`
+	ProfileImageURL string    `json:"profileImageUrl,omitempty"`
+	FirstSeen       time.Time `json:"firstSeen"`
+	LastSeen        time.Time `json:"lastSeen"`
+	// Messages counts the chat messages and Super Chats.
+	Messages int `json:"messages"`
+	// SuperChatMicros totals the Super Chats and Super Stickers in micros by currency.
+	SuperChatMicros map[string]int64 `json:"superChatMicros,omitempty"`
+	Member          bool             `json:"member"`
+	// MemberLevel is the membership level of the latest membership event, if any.
+	MemberLevel string `json:"memberLevel,omitempty"`
+	Moderator   bool   `json:"moderator"`
+	Owner       bool   `json:"owner"`
+}
+
+// Viewers tracks the users of a chat session from its events, see Observe and Middleware.
+// The session lasts until Reset.
+type Viewers struct {
+	mu    sync.Mutex
+	stats map[string]*ViewerStats
+}
+
+func NewViewers() *Viewers {
+	return &Viewers{stats: make(map[string]*ViewerStats)}
+}
+
+// Middleware observes every event and delivers a FirstMessageEvent right before the first
+// chat message of every user, for EventMiddleware.
+func (v *Viewers) Middleware() Middleware {
+	return func(event LiveEvent) []LiveEvent {
+		if first := v.Observe(event); first != nil {
+			return []LiveEvent{first, event}
+		}
+		return []LiveEvent{event}
+	}
+}
+
+// Observe updates the statistics with the event. It returns a FirstMessageEvent when the
+// event is the first chat message or Super Chat of the user in the session.
+func (v *Viewers) Observe(event LiveEvent) *FirstMessageEvent {
+	var (
+		author    AuthorDetails
+		ts        time.Time
+		message   string
+		messageID string
+		isMessage bool
+		micros    int64
+		currency  string
+		level     string
+	)
+	switch e := event.(type) {
+	case *ChatMessageEvent:
+		author, ts, message, messageID, isMessage = e.AuthorDetails, e.Timestamp, e.Message, e.MessageID, true
+	case *SuperChatEvent:
+		author, ts, message, isMessage = e.AuthorDetails, e.Timestamp, e.Message, true
+		micros, currency = e.AmountMicros, e.Currency
+	case *SuperStickerEvent:
+		author, ts = e.AuthorDetails, e.Timestamp
+		micros, currency = e.AmountMicros, e.Currency
+	case *MemberMilestoneEvent:
+		author, ts, level = e.AuthorDetails, e.Timestamp, e.Level
+	case *NewMemberEvent:
+		author, ts, level = e.AuthorDetails, e.Timestamp, e.Level
+	case *MembershipGiftEvent:
+		author, ts = e.AuthorDetails, e.Timestamp
+	default:
+		return nil
+	}
+	if author.ChannelId == "" {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	stats, ok := v.stats[author.ChannelId]
+	if !ok {
+		stats = &ViewerStats{ChannelID: author.ChannelId, FirstSeen: ts}
+		v.stats[author.ChannelId] = stats
+	}
+	if ts.After(stats.LastSeen) {
+		stats.LastSeen = ts
+	}
+	stats.DisplayName = author.DisplayName
+	if author.ProfileImageUrl != "" {
+		stats.ProfileImageURL = author.ProfileImageUrl
+	}
+	stats.Member = author.IsChatSponsor || level != "" || stats.Member
+	if level != "" {
+		stats.MemberLevel = level
+	}
+	stats.Moderator, stats.Owner = author.IsChatModerator, author.IsChatOwner
+	if currency != "" {
+		if stats.SuperChatMicros == nil {
+			stats.SuperChatMicros = make(map[string]int64)
+		}
+		stats.SuperChatMicros[currency] += micros
+	}
+	if !isMessage {
+		return nil
+	}
+	stats.Messages++
+	if stats.Messages > 1 {
+		return nil
+	}
+	return &FirstMessageEvent{
+		MessageID:     messageID,
+		Message:       message,
+		DisplayName:   author.DisplayName,
+		AuthorDetails: author,
+		Timestamp:     ts,
+	}
+}
+
+// Get returns the statistics of the user.
+func (v *Viewers) Get(channelID string) (ViewerStats, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	stats, ok := v.stats[channelID]
+	if !ok {
+		return ViewerStats{}, false
+	}
+	return stats.clone(), true
+}
+
+// All returns the statistics of every user, the first seen first.
+func (v *Viewers) All() []ViewerStats {
+	return v.query(func(*ViewerStats) bool { return true }, func(a, b ViewerStats) int {
+		return a.FirstSeen.Compare(b.FirstSeen)
+	})
+}
+
+// TopChatters returns at most n users with the most messages, ties are ordered by who
+// chatted first.
+func (v *Viewers) TopChatters(n int) []ViewerStats {
+	top := v.query(func(s *ViewerStats) bool { return s.Messages > 0 }, func(a, b ViewerStats) int {
+		return cmp.Or(b.Messages-a.Messages, a.FirstSeen.Compare(b.FirstSeen))
+	})
+	return top[:min(n, len(top))]
+}
+
+// Members returns the members seen since the time, the most recently seen first. Use the
+// zero time for every member of the session.
+func (v *Viewers) Members(seenSince time.Time) []ViewerStats {
+	return v.query(func(s *ViewerStats) bool {
+		return s.Member && !s.LastSeen.Before(seenSince)
+	}, func(a, b ViewerStats) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+}
+
+// Len returns the number of users seen in the session.
+func (v *Viewers) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.stats)
+}
+
+// Reset starts a new session, every user chats for the first time again.
+func (v *Viewers) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	clear(v.stats)
+}
+
+func (v *Viewers) query(keep func(*ViewerStats) bool, compare func(a, b ViewerStats) int) []ViewerStats {
+	v.mu.Lock()
+	var stats []ViewerStats
+	for _, s := range v.stats {
+		if keep(s) {
+			stats = append(stats, s.clone())
+		}
+	}
+	v.mu.Unlock()
+	slices.SortFunc(stats, compare)
+	return stats
+}
+
+func (s *ViewerStats) clone() ViewerStats {
+	c := *s
+	c.SuperChatMicros = maps.Clone(s.SuperChatMicros)
+	return c
+}
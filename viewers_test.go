@@ -0,0 +1,80 @@
+package youtubelive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewers_Stats(t *testing.T) {
+	start := time.Unix(1000, 0).UTC()
+	alice := AuthorDetails{ChannelId: "UCalice", DisplayName: "alice", ProfileImageUrl: "https://img/alice"}
+	bob := AuthorDetails{ChannelId: "UCbob", DisplayName: "bob", IsChatSponsor: true}
+	carol := AuthorDetails{ChannelId: "UCcarol", DisplayName: "carol"}
+
+	v := NewViewers()
+	events := []LiveEvent{
+		&ChatMessageEvent{MessageID: "1", Message: "hi", AuthorDetails: alice, Timestamp: start},
+		&ChatMessageEvent{MessageID: "2", Message: "hello", AuthorDetails: bob, Timestamp: start.Add(time.Second)},
+		&SuperChatEvent{Message: "gg", AmountMicros: 4_990_000, Currency: "USD", AuthorDetails: alice, Timestamp: start.Add(2 * time.Second)},
+		&SuperStickerEvent{AmountMicros: 100_000, Currency: "USD", AuthorDetails: alice, Timestamp: start.Add(3 * time.Second)},
+		&SuperStickerEvent{AmountMicros: 2_000_000, Currency: "EUR", AuthorDetails: alice, Timestamp: start.Add(3 * time.Second)},
+		&NewMemberEvent{Level: "Gold", AuthorDetails: carol, Timestamp: start.Add(4 * time.Second)},
+		&ChatMessageEvent{MessageID: "3", Message: "again", AuthorDetails: AuthorDetails{ChannelId: "UCalice", DisplayName: "alice2"}, Timestamp: start.Add(5 * time.Second)},
+	}
+	var first []string
+	for _, event := range events {
+		if e := v.Observe(event); e != nil {
+			first = append(first, e.MessageID)
+		}
+	}
+	assert.Equal(t, []string{"1", "2"}, first)
+	assert.Equal(t, 3, v.Len())
+
+	stats, ok := v.Get("UCalice")
+	if assert.True(t, ok) {
+		assert.Equal(t, "alice2", stats.DisplayName, "the latest display name")
+		assert.Equal(t, "https://img/alice", stats.ProfileImageURL)
+		assert.Equal(t, start, stats.FirstSeen)
+		assert.Equal(t, start.Add(5*time.Second), stats.LastSeen)
+		assert.Equal(t, 3, stats.Messages)
+		assert.Equal(t, map[string]int64{"USD": 5_090_000, "EUR": 2_000_000}, stats.SuperChatMicros)
+		assert.False(t, stats.Member)
+	}
+
+	top := v.TopChatters(5)
+	if assert.Len(t, top, 2) {
+		assert.Equal(t, "UCalice", top[0].ChannelID)
+		assert.Equal(t, "UCbob", top[1].ChannelID)
+	}
+	assert.Len(t, v.TopChatters(1), 1)
+
+	members := v.Members(time.Time{})
+	if assert.Len(t, members, 2) {
+		assert.Equal(t, "UCcarol", members[0].ChannelID)
+		assert.Equal(t, "Gold", members[0].MemberLevel)
+		assert.Equal(t, "UCbob", members[1].ChannelID)
+	}
+	assert.Len(t, v.Members(start.Add(2*time.Second)), 1)
+
+	v.Reset()
+	assert.Zero(t, v.Len())
+	assert.NotNil(t, v.Observe(events[0]), "first message of a new session")
+}
+
+func TestViewers_Middleware(t *testing.T) {
+	viewer := AuthorDetails{ChannelId: "UCviewer"}
+	middleware := NewViewers().Middleware()
+
+	got := middleware(chatFrom(viewer, "hi"))
+	if assert.Len(t, got, 2) {
+		first, ok := got[0].(*FirstMessageEvent)
+		if assert.True(t, ok) {
+			assert.Equal(t, "id-hi", first.MessageID)
+			assert.Equal(t, "UCviewer", first.AuthorDetails.ChannelId)
+		}
+	}
+	assert.Len(t, middleware(chatFrom(viewer, "again")), 1)
+	assert.Len(t, middleware(&ChatEndedEvent{}), 1)
+}
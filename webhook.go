@@ -0,0 +1,280 @@
+package youtubelive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers set on every webhook request, see VerifyWebhookSignature.
+const (
+	WebhookSignatureHeader = "X-YouTubeLive-Signature"
+	WebhookTimestampHeader = "X-YouTubeLive-Timestamp"
+)
+
+// Webhook is a URL that WebhookSink posts events to. When Secret is set the requests are
+// signed with it.
+type Webhook struct {
+	URL    string
+	Secret string
+}
+
+type WebhookOption func(s *WebhookSink)
+
+// WebhookBatch sets the most events sent in one request and how long to wait for more
+// events before sending a request that is not full. The default is 1 event, sent
+// immediately.
+func WebhookBatch(size int, wait time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.batchSize = max(size, 1)
+		s.batchWait = wait
+	}
+}
+
+// WebhookRetries sets how many times a failed request is retried, waiting backoff before
+// the first retry and doubling it for every following one. The default is 3 retries
+// starting at 1 second.
+func WebhookRetries(retries int, backoff time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.retries = max(retries, 0)
+		s.backoff = backoff
+	}
+}
+
+// WebhookDeadLetter sets where batches that could not be delivered after the retries are
+// written, one JSON line per webhook and batch. By default they are only logged.
+func WebhookDeadLetter(w io.Writer) WebhookOption {
+	return func(s *WebhookSink) {
+		s.deadLetter = w
+	}
+}
+
+// WebhookClient sets the HTTP client used for the requests, the default has a 10 second
+// timeout.
+func WebhookClient(client *http.Client) WebhookOption {
+	return func(s *WebhookSink) {
+		s.client = client
+	}
+}
+
+// WebhookLogger sets the logger for failed deliveries, the default is slog.Default().
+func WebhookLogger(log *slog.Logger) WebhookOption {
+	return func(s *WebhookSink) {
+		s.log = log
+	}
+}
+
+// WebhookSink posts the events of an attached chat to webhooks so several services can use
+// one Attach. Every request is a JSON array of EventEnvelope, see MarshalEvent.
+type WebhookSink struct {
+	webhooks  []Webhook
+	client    *http.Client
+	log       *slog.Logger
+	batchSize int
+	batchWait time.Duration
+	retries   int
+	backoff   time.Duration
+	now       func() time.Time
+
+	deadLetterMu sync.Mutex
+	deadLetter   io.Writer
+}
+
+func NewWebhookSink(webhooks []Webhook, options ...WebhookOption) *WebhookSink {
+	s := &WebhookSink{
+		webhooks:  webhooks,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		log:       slog.Default(),
+		batchSize: 1,
+		retries:   3,
+		backoff:   time.Second,
+		now:       time.Now,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// webhookShutdownTimeout bounds delivering the pending batch once the context of Run is
+// done, the batch is dead-lettered when it runs out.
+const webhookShutdownTimeout = 5 * time.Second
+
+// Run posts the events until the events channel is closed or ctx is done. A batch is sent
+// to all webhooks at once, the next batch is sent after every webhook accepted the batch
+// or failed all its retries. When ctx is done the pending batch is still delivered, for at
+// most 5 seconds.
+func (s *WebhookSink) Run(ctx context.Context, events <-chan LiveEvent) error {
+	var (
+		batch []json.RawMessage
+		timer *time.Timer
+		flush <-chan time.Time
+	)
+	send := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, flush = nil, nil
+		}
+		if len(batch) > 0 {
+			s.deliver(ctx, batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			if len(batch) > 0 {
+				shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookShutdownTimeout)
+				s.deliver(shutdownCtx, batch)
+				cancel()
+			}
+			return ctx.Err()
+		case <-flush:
+			send()
+		case event, ok := <-events:
+			if !ok {
+				send()
+				return nil
+			}
+			data, err := MarshalEvent(event)
+			if err != nil {
+				s.log.Warn("could not encode event for webhooks", "error", err)
+				continue
+			}
+			batch = append(batch, data)
+			if len(batch) >= s.batchSize || s.batchWait <= 0 {
+				send()
+			} else if timer == nil {
+				timer = time.NewTimer(s.batchWait)
+				flush = timer.C
+			}
+		}
+	}
+}
+
+func (s *WebhookSink) deliver(ctx context.Context, batch []json.RawMessage) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		s.log.Warn("could not encode webhook batch", "error", err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, webhook := range s.webhooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.post(ctx, webhook, body); err != nil {
+				s.log.Warn("webhook delivery failed", "url", webhook.URL, "events", len(batch), "error", err)
+				s.writeDeadLetter(webhook, batch, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// errPermanent marks webhook responses that are not retried.
+var errPermanent = errors.New("webhook rejected request")
+
+func (s *WebhookSink) post(ctx context.Context, webhook Webhook, body []byte) error {
+	backoff := s.backoff
+	var err error
+	for attempt := 0; attempt <= s.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		err = s.postOnce(ctx, webhook, body)
+		if err == nil || errors.Is(err, errPermanent) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *WebhookSink) postOnce(ctx context.Context, webhook Webhook, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		timestamp := strconv.FormatInt(s.now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, signWebhook(webhook.Secret, timestamp, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return fmt.Errorf("%w: status %d", errPermanent, resp.StatusCode)
+}
+
+func (s *WebhookSink) writeDeadLetter(webhook Webhook, batch []json.RawMessage, err error) {
+	if s.deadLetter == nil {
+		return
+	}
+	line, encodeErr := json.Marshal(struct {
+		At     time.Time         `json:"at"`
+		URL    string            `json:"url"`
+		Error  string            `json:"error"`
+		Events []json.RawMessage `json:"events"`
+	}{s.now().UTC(), webhook.URL, err.Error(), batch})
+	if encodeErr != nil {
+		s.log.Warn("could not encode dead letter", "error", encodeErr)
+		return
+	}
+	s.deadLetterMu.Lock()
+	defer s.deadLetterMu.Unlock()
+	if _, err := s.deadLetter.Write(append(line, '\n')); err != nil {
+		s.log.Warn("could not write dead letter", "error", err)
+	}
+}
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether the signature and timestamp headers of a webhook
+// request match the body, for receivers of a WebhookSink. Requests older than maxAge are
+// rejected to prevent replays, a maxAge of 0 accepts any age.
+func VerifyWebhookSignature(secret string, header http.Header, body []byte, maxAge time.Duration) bool {
+	timestamp := header.Get(WebhookTimestampHeader)
+	if maxAge > 0 {
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || time.Since(time.Unix(unix, 0)).Abs() > maxAge {
+			return false
+		}
+	}
+	expected := signWebhook(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(header.Get(WebhookSignatureHeader)))
+}
@@ -0,0 +1,118 @@
+package youtubelive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver records the batches posted to it and responds with the queued statuses.
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	batches  [][]LiveEvent
+	verified []bool
+}
+
+func newWebhookReceiver(t *testing.T, secret string, statuses ...int) *webhookReceiver {
+	rcv := &webhookReceiver{statuses: statuses}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		status := http.StatusOK
+		if len(rcv.statuses) > 0 {
+			status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+		}
+		if status == http.StatusOK {
+			var envelopes []json.RawMessage
+			assert.NoError(t, json.Unmarshal(body, &envelopes))
+			var batch []LiveEvent
+			for _, envelope := range envelopes {
+				event, err := UnmarshalEvent(envelope)
+				assert.NoError(t, err)
+				batch = append(batch, event)
+			}
+			rcv.batches = append(rcv.batches, batch)
+			rcv.verified = append(rcv.verified, VerifyWebhookSignature(secret, r.Header, body, time.Minute))
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func TestWebhookSink_Batches(t *testing.T) {
+	rcv := newWebhookReceiver(t, "secret", http.StatusInternalServerError)
+	sink := NewWebhookSink([]Webhook{{URL: rcv.URL, Secret: "secret"}},
+		WebhookBatch(2, time.Hour),
+		WebhookRetries(1, time.Millisecond))
+
+	events := make(chan LiveEvent, 10)
+	events <- chatFrom(AuthorDetails{}, "one")
+	events <- chatFrom(AuthorDetails{}, "two")
+	events <- chatFrom(AuthorDetails{}, "three")
+	close(events)
+	assert.NoError(t, sink.Run(context.Background(), events))
+
+	assert.Equal(t, [][]LiveEvent{
+		{chatFrom(AuthorDetails{}, "one"), chatFrom(AuthorDetails{}, "two")},
+		{chatFrom(AuthorDetails{}, "three")},
+	}, rcv.batches, "the first batch is retried and the rest is sent when events is closed")
+	assert.Equal(t, []bool{true, true}, rcv.verified)
+	assert.False(t, VerifyWebhookSignature("wrong", http.Header{}, nil, 0))
+}
+
+func TestWebhookSink_DeadLetter(t *testing.T) {
+	rejecting := newWebhookReceiver(t, "", http.StatusBadRequest)
+	failing := newWebhookReceiver(t, "", http.StatusBadGateway, http.StatusBadGateway)
+	ok := newWebhookReceiver(t, "")
+	var deadLetter bytes.Buffer
+	sink := NewWebhookSink([]Webhook{{URL: rejecting.URL}, {URL: failing.URL}, {URL: ok.URL}},
+		WebhookRetries(1, time.Millisecond),
+		WebhookDeadLetter(&deadLetter))
+
+	events := make(chan LiveEvent, 1)
+	events <- chatFrom(AuthorDetails{}, "hello")
+	close(events)
+	assert.NoError(t, sink.Run(context.Background(), events))
+
+	assert.Len(t, ok.batches, 1)
+	var urls []string
+	dec := json.NewDecoder(&deadLetter)
+	for dec.More() {
+		var line struct {
+			URL    string            `json:"url"`
+			Events []json.RawMessage `json:"events"`
+		}
+		assert.NoError(t, dec.Decode(&line))
+		assert.Len(t, line.Events, 1)
+		urls = append(urls, line.URL)
+	}
+	assert.ElementsMatch(t, []string{rejecting.URL, failing.URL}, urls)
+}
+
+func TestWebhookSink_FlushOnCancel(t *testing.T) {
+	rcv := newWebhookReceiver(t, "")
+	sink := NewWebhookSink([]Webhook{{URL: rcv.URL}}, WebhookBatch(2, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan LiveEvent)
+	done := make(chan error, 1)
+	go func() {
+		done <- sink.Run(ctx, events)
+	}()
+	events <- chatFrom(AuthorDetails{}, "pending")
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, [][]LiveEvent{{chatFrom(AuthorDetails{}, "pending")}}, rcv.batches, "the pending batch is sent on shutdown")
+}
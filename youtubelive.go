@@ -4,10 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"golang.org/x/oauth2"
-	"google.golang.org/api/googleapi"
-	"google.golang.org/api/option"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"google.golang.org/api/youtube/v3"
+	"google.golang.org/grpc"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -15,6 +15,10 @@ import (
 	"time"
 )
 
+// defaultPollInterval is used until the live chat returns the interval it wants to be
+// polled at.
+const defaultPollInterval = 3 * time.Second
+
 type YouTubeLive struct {
 	ctx       context.Context
 	closeOnce sync.Once
@@ -23,12 +27,52 @@ type YouTubeLive struct {
 	transport http.RoundTripper
 	jar       http.CookieJar
 
-	resolved bool
-	yclient  *ytClient
+	// mu guards yclient and refreshToken which are replaced when the credentials change
+	// while chats may be attached.
+	mu           sync.RWMutex
+	yclient      *ytClient
+	refreshToken string
 
 	clientID     string
 	clientSecret string
-	refreshToken string
+
+	readCredentials []Credential
+	pool            *credentialPool
+	apiKey          string
+	keyPool         *credentialPool
+
+	minPollInterval time.Duration
+	maxPollInterval time.Duration
+	quotaSaver      bool
+	pollIntervals   *pollIntervals
+
+	searchFallback bool
+
+	eventBuffer int
+	overflow    OverflowPolicy
+	spillDir    string
+
+	middleware    []Middleware
+	botMiddleware []BotMiddleware
+	// middlewareMu serializes the calls of the middleware, events are emitted by the poll
+	// goroutines and by the bot event senders of every attached chat.
+	middlewareMu    sync.Mutex
+	botMiddlewareMu sync.Mutex
+	pageRecorder    *Recorder
+	banLedger       *BanLedger
+	// moderator caches the logged-in channel ID of the client for the ban ledger.
+	moderatorMu sync.Mutex
+	moderator   struct {
+		client    *ytClient
+		channelID string
+	}
+	metrics      *Metrics
+	tracer       trace.Tracer
+	messageSpans *messageSpans
+
+	streamChat            bool
+	streamListAddr        string
+	streamListDialOptions []grpc.DialOption
 
 	listenAddr        string
 	additionalScopes  []string
@@ -43,6 +87,12 @@ func NewYouTubeLive(clientID, clientSecret string, options ...Option) (*YouTubeL
 	yt.clientID = clientID
 	yt.clientSecret = clientSecret
 	yt.listenAddr = "127.0.0.1:0"
+	yt.streamListAddr = defaultStreamListAddr
+	yt.eventBuffer = defaultEventBuffer
+	yt.searchFallback = true
+	yt.pollIntervals = newPollIntervals()
+	yt.tracer = noop.NewTracerProvider().Tracer(tracerName)
+	yt.messageSpans = newMessageSpans()
 
 	var errs error
 	for _, option := range options {
@@ -56,7 +106,21 @@ func NewYouTubeLive(clientID, clientSecret string, options ...Option) (*YouTubeL
 		yt.log.Error("error while processing options", "error", errs)
 		return nil, errs
 	}
-	yt.newYtClient()
+	yt.yclient = yt.newYtClient(yt.refreshToken)
+	if len(yt.readCredentials) > 0 {
+		pool, err := yt.newCredentialPool(yt.readCredentials)
+		if err != nil {
+			return nil, err
+		}
+		yt.pool = pool
+	}
+	if yt.apiKey != "" {
+		keyPool, err := yt.newCredentialPool([]Credential{{APIKey: yt.apiKey}})
+		if err != nil {
+			return nil, err
+		}
+		yt.keyPool = keyPool
+	}
 	return yt, nil
 }
 
@@ -64,88 +128,47 @@ func NewYouTubeLive(clientID, clientSecret string, options ...Option) (*YouTubeL
 // for Attach(). If multiple queries for a user's broadcast is required, to optimize quota usage it is recommended to get the channelID first from
 // the channel handle and call CurrentBroadcastIDFromChannelID for this query. Will return NotLiveError when broadcast ID isn't found.
 func (yt *YouTubeLive) CurrentBroadcastIDFromChannelHandle(channelName string) (string, error) {
-	channelID, err := yt.ChannelIDFromChannelHandle(channelName)
+	return yt.CurrentBroadcastIDFromChannelHandleContext(context.Background(), channelName)
+}
+
+// CurrentBroadcastIDFromChannelHandleContext is CurrentBroadcastIDFromChannelHandle with
+// a context used for every request.
+func (yt *YouTubeLive) CurrentBroadcastIDFromChannelHandleContext(ctx context.Context, channelName string) (string, error) {
+	channelID, err := yt.ChannelIDFromChannelHandleContext(ctx, channelName)
 	if err != nil {
 		return "", err
 	}
-	return yt.CurrentBroadcastIDFromChannelID(channelID)
+	return yt.CurrentBroadcastIDFromChannelIDContext(ctx, channelID)
 }
 
 // CurrentBroadcastIDFromChannelID returns the current liver broadcastID which can be used for Attach(). Will return NotLiveError when a current live broadcast is not found.
 func (yt *YouTubeLive) CurrentBroadcastIDFromChannelID(channelID string) (string, error) {
-	err := yt.yclient.refresh()
-	if err != nil {
-		return "", err
-	}
-	channelsResp, err := yt.yclient.service.Channels.List([]string{"contentDetails"}).
-		Id(channelID).
-		Do()
-	err = wrapOauthErrors(err)
-	if err != nil {
-		return "", fmt.Errorf("failed to get channel details: %v", err)
-	}
-
-	if len(channelsResp.Items) == 0 {
-		return "", errors.New("channel not found")
-	}
-
-	uploadsPlaylist := channelsResp.Items[0].ContentDetails.RelatedPlaylists.Uploads
-
-	playlistResp, err := yt.yclient.service.PlaylistItems.List([]string{"contentDetails"}).
-		PlaylistId(uploadsPlaylist).
-		MaxResults(50). // Check last 5 videos
-		Do()
-	err = wrapOauthErrors(err)
-	if err != nil {
-		return "", fmt.Errorf("failed to get uploads: %v", err)
-	}
-
-	for _, item := range playlistResp.Items {
-		videoID := item.ContentDetails.VideoId
-
-		videoResp, err := yt.yclient.service.Videos.List([]string{"liveStreamingDetails"}).
-			Id(videoID).
-			Do()
-		err = wrapOauthErrors(err)
-		if err != nil || len(videoResp.Items) == 0 {
-			continue
-		}
-
-		details := videoResp.Items[0].LiveStreamingDetails
-		if details != nil && details.ActualStartTime != "" && details.ActualEndTime == "" {
-			return videoID, nil
-		}
-	}
+	return yt.CurrentBroadcastIDFromChannelIDContext(context.Background(), channelID)
+}
 
-	// The above works most of the time and is cheaper than a search, and search sometimes doesn't work when the authenticated
-	// user is not the owner and subscriber only chat.  Anyway, fallback to search now and if it still doesn't work there isn't
-	// currently a known workaround through the API.
-	qResp, err := yt.yclient.service.Search.List([]string{"snippet", "id"}).
-		ChannelId(channelID).
-		EventType("live").
-		Q(channelID).
-		Type("video").
-		Do()
-	err = wrapOauthErrors(err)
-	if err != nil {
-		return "", fmt.Errorf("failed to get search results: %v", err)
-	}
-	if len(qResp.Items) == 0 {
-		return "", NotLiveError
-	}
-	return qResp.Items[0].Id.VideoId, nil
+// CurrentBroadcastIDFromChannelIDContext is CurrentBroadcastIDFromChannelID with a
+// context used for every request.
+func (yt *YouTubeLive) CurrentBroadcastIDFromChannelIDContext(ctx context.Context, channelID string) (string, error) {
+	broadcast, err := yt.FindLiveBroadcast(ctx, channelID)
+	return broadcast.BroadcastID, err
 }
 
 // LoggedInChannel return the name and channel ID of the logged-in user auth channel. Can
 // return an error, such as NotLoggedIn
 func (yt *YouTubeLive) LoggedInChannel() (string, string, error) {
-	err := yt.yclient.refresh()
+	return yt.LoggedInChannelContext(context.Background())
+}
+
+// LoggedInChannelContext is LoggedInChannel with a context used for the request.
+func (yt *YouTubeLive) LoggedInChannelContext(ctx context.Context) (string, string, error) {
+	service, err := yt.service()
 	if err != nil {
-		return "", "", wrapOauthErrors(err)
+		return "", "", err
 	}
 	// TODO handle multiple channelIDs
-	resp, err := yt.yclient.service.Channels.List([]string{"snippet", "id"}).Mine(true).Do()
-	err = wrapOauthErrors(err)
+	resp, err := service.Channels.List([]string{"snippet", "id"}).Mine(true).Fields(channelTitleFields).Context(ctx).Do()
+	yt.metrics.observeAPI("channels.list", err)
+	err = wrapAPIError("channels.list", err)
 	if err != nil {
 		return "", "", err
 	}
@@ -155,183 +178,288 @@ func (yt *YouTubeLive) LoggedInChannel() (string, string, error) {
 	return resp.Items[0].Snippet.Title, resp.Items[0].Id, nil
 }
 
+// ChannelIDFromChannelHandle returns the channel ID for the handle, the @ prefix is
+// optional. Will return ErrUserNotFound when no channel has the handle.
 func (yt *YouTubeLive) ChannelIDFromChannelHandle(channelName string) (string, error) {
+	return yt.ChannelIDFromChannelHandleContext(context.Background(), channelName)
+}
+
+// ChannelIDFromChannelHandleContext is ChannelIDFromChannelHandle with a context used for
+// the request.
+func (yt *YouTubeLive) ChannelIDFromChannelHandleContext(ctx context.Context, channelName string) (string, error) {
 	if len(channelName) < 1 {
 		return "", InvalidYouTubeChannelName
 	}
 	if channelName[0] != '@' {
 		channelName = "@" + channelName
 	}
-	err := yt.yclient.refresh()
+	var resp *youtube.ChannelListResponse
+	err := yt.readPublic("channels.list", func(service *youtube.Service) (err error) {
+		resp, err = service.Channels.List([]string{"id"}).ForHandle(channelName).Fields(channelIDFields).Context(ctx).Do()
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
-	resp, err := yt.yclient.service.Channels.List([]string{"id"}).ForHandle(channelName).Do()
-	if err := wrapOauthErrors(err); err != nil {
-		return "", err
-	}
 	if len(resp.Items) == 0 {
-		return "", fmt.Errorf("user does not exist")
+		return "", ErrUserNotFound
 	}
 	return resp.Items[0].Id, nil
 }
 
 // IsLive will return true when channel is live.
 func (yt *YouTubeLive) IsLive(channelID string) (bool, error) {
-	if _, err := yt.CurrentBroadcastIDFromChannelID(channelID); err != nil {
+	return yt.IsLiveContext(context.Background(), channelID)
+}
+
+// IsLiveContext is IsLive with a context used for every request.
+func (yt *YouTubeLive) IsLiveContext(ctx context.Context, channelID string) (bool, error) {
+	if _, err := yt.CurrentBroadcastIDFromChannelIDContext(ctx, channelID); err != nil {
 		if errors.Is(err, NotLiveError) {
 			return false, nil
 		}
-		return false, wrapOauthErrors(err)
+		return false, err
 	}
 	return true, nil
 }
 
-// Attach to a live broadcast.  The returned out channel are all live events and the input channel are for chat events to send to broadcast.  A closed LiveEvent out channel indicates the live broadcast has ended or an error occurred which would require another Attach. By closing the in BotEvent channel, this will the close sending side of the attached connection but the ctx parameter must be canceled to trigger full cleanup of the attached routines.
+// Attach to a live broadcast.  The returned out channel are all live events and the input channel are for chat events to send to broadcast.  A closed LiveEvent out channel indicates the live broadcast has ended or an error occurred which would require another Attach. Errors that are retryable, see IsRetryable, and network errors are delivered as an ErrorEvent and polling continues, other API errors are delivered as an ErrorEvent followed by a ChatEndedEvent. By closing the in BotEvent channel, this will the close sending side of the attached connection but the ctx parameter must be canceled to trigger full cleanup of the attached routines.
 func (yt *YouTubeLive) Attach(ctx context.Context, broadcastID string) (<-chan LiveEvent, chan<- BotEvent, error) {
-	err := yt.yclient.refresh()
+	attachCtx, span := yt.tracer.Start(ctx, "youtubelive.Attach", trace.WithAttributes(AttrBroadcastID.String(broadcastID)))
+	liveChatID, err := yt.getLiveChatID(attachCtx, broadcastID)
+	span.SetAttributes(AttrLiveChatID.String(liveChatID))
+	endSpan(span, err)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	liveChatID, err := yt.getLiveChatID(broadcastID)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	outChan := make(chan LiveEvent, 100)
 	inChan := make(chan BotEvent, 100)
+	// events is written by the goroutines below.
+	events, outChan, backlog := yt.newEventChannel(ctx)
+	yt.metrics.attach(outChan, backlog)
 
-	var wg sync.WaitGroup
-	ctx, cancel := context.WithCancel(ctx)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer close(outChan)
-		yt.pollLiveChat(ctx, liveChatID, outChan)
-	}()
+	// The polls and bot events have their own traces linked to the Attach span.
+	chatCtx, cancel := context.WithCancel(trace.ContextWithSpanContext(ctx, span.SpanContext()))
 
-	wg.Add(1)
+	var bots sync.WaitGroup
+	bots.Add(1)
 	go func() {
-		defer wg.Done()
-		yt.handleBotEvents(ctx, liveChatID, inChan, outChan)
+		defer bots.Done()
+		yt.handleBotEvents(chatCtx, liveChatID, inChan, events)
 	}()
 
 	go func() {
-		<-ctx.Done()
+		defer yt.metrics.detach(outChan)
+		yt.receiveLiveChat(chatCtx, liveChatID, events)
+		// Stop sending bot events before closing the channel their errors are delivered on.
 		cancel()
-		wg.Wait()
+		bots.Wait()
+		close(events)
 	}()
 
 	return outChan, inChan, nil
 }
 
-func (yt *YouTubeLive) getLiveChatID(broadcastID string) (string, error) {
-	call := yt.yclient.service.Videos.List([]string{"liveStreamingDetails"}).
-		Id(broadcastID).
-		MaxResults(1)
+func (yt *YouTubeLive) getLiveChatID(ctx context.Context, broadcastID string) (string, error) {
+	chat, err := yt.getLiveChat(ctx, broadcastID)
+	return chat.liveChatID, err
+}
 
-	resp, err := call.Do()
-	err = wrapOauthErrors(err)
+// liveChat identifies the live chat of a broadcast.
+type liveChat struct {
+	liveChatID string
+	channelID  string
+}
+
+func (yt *YouTubeLive) getLiveChat(ctx context.Context, broadcastID string) (chat liveChat, err error) {
+	ctx, span := yt.tracer.Start(ctx, "youtubelive.getLiveChatID", trace.WithAttributes(AttrBroadcastID.String(broadcastID)))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	var resp *youtube.VideoListResponse
+	err = yt.readPublic("videos.list", func(service *youtube.Service) (err error) {
+		resp, err = service.Videos.List([]string{"snippet", "liveStreamingDetails"}).
+			Id(broadcastID).
+			MaxResults(1).
+			Fields(liveChatVideoFields).
+			Context(ctx).
+			Do()
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get broadcast: %w", err)
+		return chat, fmt.Errorf("failed to get broadcast: %w", err)
 	}
 
 	if len(resp.Items) == 0 {
-		return "", ErrBroadcastNotFound
+		return chat, ErrBroadcastNotFound
 	}
 
-	liveChatID := resp.Items[0].LiveStreamingDetails.ActiveLiveChatId
-	if liveChatID == "" {
-		return "", ErrChatDisabled
+	video := resp.Items[0]
+	if video.LiveStreamingDetails == nil || video.LiveStreamingDetails.ActiveLiveChatId == "" {
+		return chat, ErrChatDisabled
 	}
-
-	return liveChatID, nil
+	chat.liveChatID = video.LiveStreamingDetails.ActiveLiveChatId
+	if video.Snippet != nil {
+		chat.channelID = video.Snippet.ChannelId
+	}
+	return chat, nil
 }
 
-func (yt *YouTubeLive) pollLiveChat(ctx context.Context, liveChatID string, out chan<- LiveEvent) {
-	var (
-		nextPageToken  string
-		pollInterval   = 3 * time.Second // Initial default
-		forceFirstPoll = true
-	)
+// receiveLiveChat sends the events of the live chat to out until the chat ends or ctx is
+// done. The streaming transport is used when enabled with StreamChat and polling
+// continues where the stream left off if it is unavailable.
+func (yt *YouTubeLive) receiveLiveChat(ctx context.Context, liveChatID string, out chan<- LiveEvent) {
+	var pageToken string
+	if yt.streamChat {
+		var done bool
+		pageToken, done = yt.streamLiveChat(ctx, liveChatID, out)
+		if done {
+			return
+		}
+	}
+	yt.pollLiveChat(ctx, liveChatID, pageToken, out)
+}
 
+func (yt *YouTubeLive) pollLiveChat(ctx context.Context, liveChatID string, pageToken string, out chan<- LiveEvent) {
+	interval := defaultPollInterval
+	// The first poll is immediate, the timer is reset to the interval the chat asks for.
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	poll := yt.startPolling(liveChatID)
+	defer yt.forgetPollInterval(poll)
 	for {
-		timer := time.NewTimer(pollInterval)
-		if forceFirstPoll {
-			timer.Stop() // Immediate first poll
-			forceFirstPoll = false
-		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(pollInterval):
-			resp, err := yt.yclient.service.LiveChatMessages.List(liveChatID, []string{"snippet", "authorDetails"}).
-				PageToken(nextPageToken).
-				Do()
-			err = wrapOauthErrors(err)
-
-			if err != nil {
-				yt.log.Debug("live chat poll failed", "error", err)
-				gerr := &googleapi.Error{}
-				if errors.As(err, &gerr) {
-					// TODO make the return behavior optional
-					select {
-					case out <- &ErrorEvent{
-						Timestamp: time.Now().UTC(),
-						Error:     gerr,
-					}:
-					case <-ctx.Done():
-					}
-
-					select {
-					case out <- &ChatEndedEvent{
-						Timestamp: time.Now().UTC(),
-					}:
-					case <-ctx.Done():
-					}
-					return
-				}
-				// TODO make the return behavior optional
-				select {
-				case out <- &ErrorEvent{
-					Timestamp: time.Now().UTC(),
-					Error:     err,
-				}:
-				case <-ctx.Done():
-				}
-				continue
-			}
+		case <-timer.C:
+		}
+		var ended bool
+		pageToken, interval, ended = yt.pollOnce(ctx, poll, pageToken, interval, out, nil)
+		if ended {
+			return
+		}
+		timer.Reset(interval)
+	}
+}
 
-			nextPageToken = resp.NextPageToken
-			if resp.PollingIntervalMillis > 0 {
-				pollInterval = time.Duration(resp.PollingIntervalMillis) * time.Millisecond
-			}
-			for _, msg := range resp.Items {
-				event, err := yt.parseChatMessage(msg, resp.NextPageToken)
-				if err != nil {
-					yt.log.Warn("failed to parse chat message", "error", err)
-					continue
-				}
-				select {
-				case out <- event:
-				case <-ctx.Done():
-				}
-				if _, ok := event.(*ChatEndedEvent); ok {
-					return
-				}
-			}
+// pollOnce requests the page of chat messages at pageToken and sends its events to out. It
+// returns the page token and interval of the next poll and whether the chat has ended,
+// after a failed request the same page is polled again. release, when not nil, is called
+// once the request is done, before the events are sent. A failed request is delivered as
+// an ErrorEvent. The chat ends after it only when the error is an APIError that is not
+// retryable, rate limits, server errors and network errors are polled through on purpose
+// so a short outage does not end the chat.
+func (yt *YouTubeLive) pollOnce(ctx context.Context, poll pollKey, pageToken string, interval time.Duration, out chan<- LiveEvent, release func()) (string, time.Duration, bool) {
+	liveChatID := poll.liveChatID
+	// The service is fetched every poll so credentials swapped with SetRefreshToken
+	// or ForceLogin are picked up by attached chats.
+	pollCtx, span := yt.startLinked(ctx, "youtubelive.poll",
+		AttrLiveChatID.String(liveChatID),
+		AttrPageToken.String(pageToken),
+		AttrPollInterval.Int64(interval.Milliseconds()))
+	var resp *youtube.LiveChatMessageListResponse
+	err := yt.read("liveChatMessages.list", func(service *youtube.Service) (err error) {
+		resp, err = service.LiveChatMessages.List(liveChatID, []string{"snippet", "authorDetails"}).
+			PageToken(pageToken).
+			Fields(liveChatMessagesFields).
+			Context(pollCtx).
+			Do()
+		return err
+	})
+	if release != nil {
+		release()
+	}
+
+	if err != nil {
+		endSpan(span, err)
+		yt.log.Debug("live chat poll failed", "error", err)
+		apiErr := &APIError{}
+		if errors.As(err, &apiErr) && !apiErr.Retryable {
+			// TODO make the return behavior optional
+			yt.emit(ctx, out, &ErrorEvent{
+				Timestamp: time.Now().UTC(),
+				Error:     apiErr,
+			})
+			yt.emit(ctx, out, &ChatEndedEvent{
+				Timestamp: time.Now().UTC(),
+			})
+			return pageToken, interval, true
+		}
+		// TODO make the return behavior optional
+		yt.emit(ctx, out, &ErrorEvent{
+			Timestamp: time.Now().UTC(),
+			Error:     err,
+		})
+		return pageToken, yt.failedPollInterval(poll, interval), false
+	}
+
+	server := defaultPollInterval
+	if resp.PollingIntervalMillis > 0 {
+		server = time.Duration(resp.PollingIntervalMillis) * time.Millisecond
+	}
+	interval = yt.nextPollInterval(poll, interval, server, len(resp.Items))
+	yt.metrics.observePollInterval(liveChatID, interval)
+	span.SetAttributes(AttrItemCount.Int(len(resp.Items)))
+	ended := yt.deliverMessages(pollCtx, liveChatID, resp, out)
+	span.End()
+	return resp.NextPageToken, interval, ended
+}
+
+// deliverMessages sends the events of a page of chat messages to out. It returns true when
+// the chat has ended. The span in ctx is remembered for linking the replies to the messages.
+func (yt *YouTubeLive) deliverMessages(ctx context.Context, liveChatID string, resp *youtube.LiveChatMessageListResponse, out chan<- LiveEvent) bool {
+	if yt.pageRecorder != nil {
+		if err := yt.pageRecorder.RecordPage(resp); err != nil {
+			yt.log.Warn("failed to record chat messages", "error", err)
+		}
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	for _, msg := range resp.Items {
+		yt.messageSpans.remember(msg.Id, sc)
+		event, err := parseChatMessage(yt.log, msg, resp.NextPageToken)
+		if err != nil {
+			yt.log.Warn("failed to parse chat message", "error", err)
+			continue
+		}
+		if banned, ok := event.(*UserBannedEvent); ok {
+			yt.recordBan(func(l *BanLedger) error { return l.Record(liveChatID, banned) })
+		}
+		yt.emit(ctx, out, event)
+		if _, ok := event.(*ChatEndedEvent); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// emit delivers the event to out after running it through the EventMiddleware. It gives
+// up when ctx is done.
+func (yt *YouTubeLive) emit(ctx context.Context, out chan<- LiveEvent, event LiveEvent) {
+	yt.middlewareMu.Lock()
+	events := applyMiddleware(yt.middleware, event)
+	yt.middlewareMu.Unlock()
+	for _, event := range events {
+		select {
+		case out <- event:
+			yt.metrics.observeEvent(event)
+		case <-ctx.Done():
+			return
 		}
 	}
 }
 
-func (yt *YouTubeLive) parseChatMessage(msg *youtube.LiveChatMessage, nextPageToken string) (LiveEvent, error) {
+func parseChatMessage(log *slog.Logger, msg *youtube.LiveChatMessage, nextPageToken string) (LiveEvent, error) {
 	snippet := msg.Snippet
 	ts, err := time.Parse(time.RFC3339, snippet.PublishedAt)
 	if err != nil {
 		return nil, fmt.Errorf("invalid timestamp: %w", err)
 	}
+	authorDetails := msg.AuthorDetails
+	if authorDetails == nil {
+		// Some events, such as tombstones, are not from an author.
+		authorDetails = &youtube.LiveChatMessageAuthorDetails{}
+	}
 	baseEvent := struct {
 		NextPageToken string
 		Timestamp     time.Time
@@ -340,13 +468,31 @@ func (yt *YouTubeLive) parseChatMessage(msg *youtube.LiveChatMessage, nextPageTo
 	}{
 		NextPageToken: nextPageToken,
 		Timestamp:     ts,
-		DisplayName:   msg.AuthorDetails.DisplayName,
-		AuthorDetails: msg.AuthorDetails,
+		DisplayName:   authorDetails.DisplayName,
+		AuthorDetails: authorDetails,
+	}
+
+	unknown := &UnknownEvent{
+		MessageID:      msg.Id,
+		Type:           snippet.Type,
+		DisplayMessage: snippet.DisplayMessage,
+		AuthorDetails:  toAuthorDetails(baseEvent.AuthorDetails),
+		Timestamp:      baseEvent.Timestamp,
+		NextPageToken:  baseEvent.NextPageToken,
+	}
+	if !hasDetails(snippet) {
+		// Delivered as is rather than dropped, the chat activity is not lost.
+		log.Debug("message without details",
+			"type", snippet.Type,
+			"message_id", msg.Id,
+		)
+		return unknown, nil
 	}
 
 	switch snippet.Type {
 	case "textMessageEvent":
 		return &ChatMessageEvent{
+			MessageID:     msg.Id,
 			Message:       snippet.TextMessageDetails.MessageText,
 			DisplayName:   baseEvent.DisplayName,
 			AuthorDetails: toAuthorDetails(baseEvent.AuthorDetails),
@@ -355,23 +501,29 @@ func (yt *YouTubeLive) parseChatMessage(msg *youtube.LiveChatMessage, nextPageTo
 		}, nil
 	case "superChatEvent":
 		return &SuperChatEvent{
-			Message:       snippet.SuperChatDetails.UserComment,
-			Amount:        float64(snippet.SuperChatDetails.AmountMicros) / 1000000,
-			Currency:      snippet.SuperChatDetails.Currency,
-			DisplayName:   baseEvent.DisplayName,
-			AuthorDetails: toAuthorDetails(baseEvent.AuthorDetails),
-			Timestamp:     baseEvent.Timestamp,
-			NextPageToken: baseEvent.NextPageToken,
+			Message:             snippet.SuperChatDetails.UserComment,
+			Amount:              float64(snippet.SuperChatDetails.AmountMicros) / 1000000,
+			AmountMicros:        int64(snippet.SuperChatDetails.AmountMicros),
+			AmountDisplayString: snippet.SuperChatDetails.AmountDisplayString,
+			Currency:            snippet.SuperChatDetails.Currency,
+			Tier:                int(snippet.SuperChatDetails.Tier),
+			DisplayName:         baseEvent.DisplayName,
+			AuthorDetails:       toAuthorDetails(baseEvent.AuthorDetails),
+			Timestamp:           baseEvent.Timestamp,
+			NextPageToken:       baseEvent.NextPageToken,
 		}, nil
 	case "superStickerEvent":
 		return &SuperStickerEvent{
-			StickerID:     snippet.SuperStickerDetails.SuperStickerMetadata.StickerId,
-			Amount:        float64(snippet.SuperStickerDetails.AmountMicros) / 1000000,
-			Currency:      snippet.SuperStickerDetails.Currency,
-			DisplayName:   baseEvent.DisplayName,
-			AuthorDetails: toAuthorDetails(baseEvent.AuthorDetails),
-			Timestamp:     baseEvent.Timestamp,
-			NextPageToken: baseEvent.NextPageToken,
+			StickerID:           snippet.SuperStickerDetails.SuperStickerMetadata.StickerId,
+			Amount:              float64(snippet.SuperStickerDetails.AmountMicros) / 1000000,
+			AmountMicros:        int64(snippet.SuperStickerDetails.AmountMicros),
+			AmountDisplayString: snippet.SuperStickerDetails.AmountDisplayString,
+			Currency:            snippet.SuperStickerDetails.Currency,
+			Tier:                int(snippet.SuperStickerDetails.Tier),
+			DisplayName:         baseEvent.DisplayName,
+			AuthorDetails:       toAuthorDetails(baseEvent.AuthorDetails),
+			Timestamp:           baseEvent.Timestamp,
+			NextPageToken:       baseEvent.NextPageToken,
 		}, nil
 	case "memberMilestoneChatEvent":
 		return &MemberMilestoneEvent{
@@ -400,9 +552,6 @@ func (yt *YouTubeLive) parseChatMessage(msg *youtube.LiveChatMessage, nextPageTo
 		}, nil
 	case "userBannedEvent":
 		details := snippet.UserBannedDetails
-		if details == nil {
-			return nil, errors.New("moderation event without details")
-		}
 
 		banType := "unknown"
 		var duration time.Duration
@@ -421,7 +570,7 @@ func (yt *YouTubeLive) parseChatMessage(msg *youtube.LiveChatMessage, nextPageTo
 			BanType:               banType,
 			Duration:              duration,
 			ModeratorID:           snippet.AuthorChannelId,
-			ModeratorDisplayName:  msg.AuthorDetails.DisplayName,
+			ModeratorDisplayName:  baseEvent.DisplayName,
 			Timestamp:             baseEvent.Timestamp,
 			NextPageToken:         baseEvent.NextPageToken,
 		}, nil
@@ -430,16 +579,105 @@ func (yt *YouTubeLive) parseChatMessage(msg *youtube.LiveChatMessage, nextPageTo
 			Timestamp:     baseEvent.Timestamp,
 			NextPageToken: baseEvent.NextPageToken,
 		}, nil
+	case "newSponsorEvent":
+		details := snippet.NewSponsorDetails
+		if details == nil {
+			details = &youtube.LiveChatNewSponsorDetails{}
+		}
+		return &NewMemberEvent{
+			DisplayName:   baseEvent.DisplayName,
+			AuthorDetails: toAuthorDetails(baseEvent.AuthorDetails),
+			Level:         details.MemberLevelName,
+			IsUpgrade:     details.IsUpgrade,
+			Timestamp:     baseEvent.Timestamp,
+			NextPageToken: baseEvent.NextPageToken,
+		}, nil
+	case "messageDeletedEvent":
+		details := snippet.MessageDeletedDetails
+		return &MessageDeletedEvent{
+			DeletedMessageID:     details.DeletedMessageId,
+			ModeratorID:          snippet.AuthorChannelId,
+			ModeratorDisplayName: baseEvent.DisplayName,
+			Timestamp:            baseEvent.Timestamp,
+			NextPageToken:        baseEvent.NextPageToken,
+		}, nil
+	case "messageRetractedEvent":
+		details := snippet.MessageRetractedDetails
+		return &MessageRetractedEvent{
+			RetractedMessageID: details.RetractedMessageId,
+			DisplayName:        baseEvent.DisplayName,
+			AuthorDetails:      toAuthorDetails(baseEvent.AuthorDetails),
+			Timestamp:          baseEvent.Timestamp,
+			NextPageToken:      baseEvent.NextPageToken,
+		}, nil
+	case "sponsorOnlyModeStartedEvent", "sponsorOnlyModeEndedEvent":
+		return &MembersOnlyModeEvent{
+			Enabled:       snippet.Type == "sponsorOnlyModeStartedEvent",
+			Timestamp:     baseEvent.Timestamp,
+			NextPageToken: baseEvent.NextPageToken,
+		}, nil
+	case "tombstone":
+		return &TombstoneEvent{
+			MessageID:     msg.Id,
+			Timestamp:     baseEvent.Timestamp,
+			NextPageToken: baseEvent.NextPageToken,
+		}, nil
+	case "pollEvent":
+		details := snippet.PollDetails
+		options := make([]PollOption, 0, len(details.Metadata.Options))
+		for _, option := range details.Metadata.Options {
+			options = append(options, PollOption{
+				Text:  option.OptionText,
+				Tally: int(option.Tally),
+			})
+		}
+		return &PollEvent{
+			PollID:        msg.Id,
+			Question:      details.Metadata.QuestionText,
+			Options:       options,
+			Status:        details.Status,
+			DisplayName:   baseEvent.DisplayName,
+			AuthorDetails: toAuthorDetails(baseEvent.AuthorDetails),
+			Timestamp:     baseEvent.Timestamp,
+			NextPageToken: baseEvent.NextPageToken,
+		}, nil
 	default:
-		yt.log.Warn("unsupported message type",
+		log.Debug("unsupported message type",
 			"type", snippet.Type,
 			"message_id", msg.Id,
 			"display", snippet.DisplayMessage,
 		)
-		return nil, fmt.Errorf("unsupported message type: %s", snippet.Type)
+		return unknown, nil
 	}
 }
 
+// hasDetails reports whether the snippet has the details its type is parsed from.
+func hasDetails(s *youtube.LiveChatMessageSnippet) bool {
+	switch s.Type {
+	case "textMessageEvent":
+		return s.TextMessageDetails != nil
+	case "superChatEvent":
+		return s.SuperChatDetails != nil
+	case "superStickerEvent":
+		return s.SuperStickerDetails != nil && s.SuperStickerDetails.SuperStickerMetadata != nil
+	case "memberMilestoneChatEvent":
+		return s.MemberMilestoneChatDetails != nil
+	case "membershipGiftingEvent":
+		return s.MembershipGiftingDetails != nil
+	case "giftMembershipReceivedEvent":
+		return s.GiftMembershipReceivedDetails != nil
+	case "userBannedEvent":
+		return s.UserBannedDetails != nil && s.UserBannedDetails.BannedUserDetails != nil
+	case "messageDeletedEvent":
+		return s.MessageDeletedDetails != nil
+	case "messageRetractedEvent":
+		return s.MessageRetractedDetails != nil
+	case "pollEvent":
+		return s.PollDetails != nil && s.PollDetails.Metadata != nil
+	}
+	return true
+}
+
 func (yt *YouTubeLive) handleBotEvents(ctx context.Context, liveChatID string, in <-chan BotEvent, out chan<- LiveEvent) {
 	for {
 		select {
@@ -449,37 +687,44 @@ func (yt *YouTubeLive) handleBotEvents(ctx context.Context, liveChatID string, i
 			if !ok {
 				return
 			}
-			switch e := evt.(type) {
-			case BotChatMessage:
-				err := yt.sendChatMessage(liveChatID, e.Message)
-				if err != nil {
-					select {
-					case out <- &ErrorEvent{
-						Timestamp: time.Now().UTC(),
-						Error:     err,
-					}:
-					case <-ctx.Done():
-					}
-				}
-			case BotDeleteMessage:
-				err := yt.deleteChatMessage(e.MessageID)
-				if err != nil {
-					select {
-					case out <- &ErrorEvent{
-						Timestamp: time.Now().UTC(),
-						Error:     err,
-					}:
-					case <-ctx.Done():
-					}
-				}
-			default:
-				yt.log.Debug("received unknown bot event type", "type", fmt.Sprintf("%T", evt))
+			for _, evt := range yt.applyBotMiddleware(evt) {
+				yt.sendBotEvent(ctx, liveChatID, evt, out)
 			}
 		}
 	}
 }
 
-func (yt *YouTubeLive) sendChatMessage(liveChatID, message string) error {
+// applyBotMiddleware runs the outgoing middleware, one event at a time.
+func (yt *YouTubeLive) applyBotMiddleware(evt BotEvent) []BotEvent {
+	yt.botMiddlewareMu.Lock()
+	defer yt.botMiddlewareMu.Unlock()
+	return applyBotMiddleware(yt.botMiddleware, evt)
+}
+
+func (yt *YouTubeLive) sendBotEvent(ctx context.Context, liveChatID string, evt BotEvent, out chan<- LiveEvent) {
+	ctx, span := yt.botEventSpan(ctx, liveChatID, evt)
+	var err error
+	switch e := evt.(type) {
+	case BotChatMessage:
+		err = yt.sendChatMessage(ctx, liveChatID, e.Message)
+	case BotDeleteMessage:
+		err = yt.deleteChatMessage(ctx, e.MessageID)
+	case BotBanUser:
+		err = yt.banUser(ctx, liveChatID, e.ChannelID, e.Duration)
+	default:
+		yt.log.Debug("received unknown bot event type", "type", fmt.Sprintf("%T", evt))
+	}
+	endSpan(span, err)
+	if err != nil {
+		yt.metrics.observeSendFailure(evt)
+		yt.emit(ctx, out, &ErrorEvent{
+			Timestamp: time.Now().UTC(),
+			Error:     err,
+		})
+	}
+}
+
+func (yt *YouTubeLive) sendChatMessage(ctx context.Context, liveChatID, message string) error {
 	msg := &youtube.LiveChatMessage{
 		Snippet: &youtube.LiveChatMessageSnippet{
 			LiveChatId: liveChatID,
@@ -490,67 +735,234 @@ func (yt *YouTubeLive) sendChatMessage(liveChatID, message string) error {
 		},
 	}
 
-	_, err := yt.yclient.service.LiveChatMessages.Insert([]string{"snippet"}, msg).Do()
-	err = wrapOauthErrors(err)
-	return err
+	service, err := yt.service()
+	if err != nil {
+		return err
+	}
+	_, err = service.LiveChatMessages.Insert([]string{"snippet"}, msg).Context(ctx).Do()
+	yt.metrics.observeAPI("liveChatMessages.insert", err)
+	return wrapAPIError("liveChatMessages.insert", err)
 }
 
-// SetRefreshToken can be called to update the refresh token.  This must only be called with no attached instances.
+// SetRefreshToken can be called to update the refresh token. It is safe to call while
+// chats are attached, attached instances use the new token starting with their next
+// request.
 func (yt *YouTubeLive) SetRefreshToken(token string) {
-	// TODO: Make it so that it doesn't matter if there are attached instances
-	yt.newYtClient()
+	yt.mu.Lock()
+	defer yt.mu.Unlock()
 	yt.refreshToken = token
-	yt.yclient.refreshToken = token
+	yt.yclient = yt.newYtClient(token)
 }
 
 // Login will use the Oauth2 workflow, if required, to login. If refresh token isn't expired this will not do anything.
 // with default configuration this will run a browser to complete the login process.
 func (yt *YouTubeLive) Login() error {
-	_, err := yt.yclient.Token()
+	return yt.LoginContext(context.Background())
+}
+
+// LoginContext is Login where the Oauth2 workflow waits for the user until ctx is done.
+// Without a deadline on ctx the workflow gives up after 5 minutes.
+func (yt *YouTubeLive) LoginContext(ctx context.Context) error {
+	_, err := yt.client().Token()
 	if err != nil {
-		return yt.ForceLogin()
+		return yt.ForceLoginContext(ctx)
 	}
 	return nil
 }
 
+// ForceLogin will always run the Oauth2 workflow to obtain a new refresh token. Attached
+// instances keep using the current credentials until the login completes and then switch
+// to the new ones. Returns ErrCredentialsChanged, without switching, when SetRefreshToken
+// or another login changed the credentials while the workflow ran.
 func (yt *YouTubeLive) ForceLogin() error {
-	yt.SetRefreshToken("")
+	return yt.ForceLoginContext(context.Background())
+}
+
+// ForceLoginContext is ForceLogin where the Oauth2 workflow waits for the user until ctx
+// is done. Without a deadline on ctx the workflow gives up after 5 minutes.
+func (yt *YouTubeLive) ForceLoginContext(ctx context.Context) error {
 	// TODO: use custom workflow options when available.
-	// TODO: Make it so that it doesn't matter if there are attached instances
-	err := yt.yclient.refresh()
+	yt.mu.RLock()
+	previous := yt.yclient
+	c := yt.newYtClient("")
+	yt.mu.RUnlock()
+
+	token, err := c.login(ctx)
 	if err != nil {
 		return err
 	}
-	yt.yclient.tokenSource = yt.yclient.createTokenSource("", true)
-	_, err = yt.yclient.tokenSource.Token()
-	if err != nil {
-		return fmt.Errorf("login failed: %w", err)
+
+	if err := yt.replaceClient(previous, c, token); err != nil {
+		return err
+	}
+	if yt.onNewRefreshToken != nil {
+		yt.onNewRefreshToken(token)
 	}
+	return nil
+}
+
+// replaceClient switches to the client of a login unless previous is no longer the
+// current client, then SetRefreshToken or another login replaced it while the workflow
+// ran and the newer credentials are kept.
+func (yt *YouTubeLive) replaceClient(previous, c *ytClient, refreshToken string) error {
+	yt.mu.Lock()
+	defer yt.mu.Unlock()
+	if yt.yclient != previous {
+		return ErrCredentialsChanged
+	}
+	yt.refreshToken = refreshToken
+	yt.yclient = c
+	return nil
+}
 
-	c := oauth2.NewClient(yt.ctx, yt.yclient.tokenSource)
-	service, err := youtube.NewService(yt.ctx, option.WithHTTPClient(c))
+func (yt *YouTubeLive) deleteChatMessage(ctx context.Context, messageID string) error {
+	service, err := yt.service()
 	if err != nil {
 		return err
 	}
-	yt.yclient.service = service
+	err = service.LiveChatMessages.Delete(messageID).Context(ctx).Do()
+	yt.metrics.observeAPI("liveChatMessages.delete", err)
+	return wrapAPIError("liveChatMessages.delete", err)
+}
 
-	if yt.onNewRefreshToken != nil {
-		yt.onNewRefreshToken(yt.refreshToken)
+func (yt *YouTubeLive) banUser(ctx context.Context, liveChatID, channelID string, duration time.Duration) error {
+	ban := &youtube.LiveChatBan{
+		Snippet: &youtube.LiveChatBanSnippet{
+			LiveChatId:        liveChatID,
+			Type:              "permanent",
+			BannedUserDetails: &youtube.ChannelProfileDetails{ChannelId: channelID},
+		},
 	}
+	if duration > 0 {
+		ban.Snippet.Type = "temporary"
+		ban.Snippet.BanDurationSeconds = uint64(duration.Seconds())
+	}
+
+	service, err := yt.service()
+	if err != nil {
+		return err
+	}
+	_, err = service.LiveChatBans.Insert([]string{"snippet"}, ban).Context(ctx).Do()
+	yt.metrics.observeAPI("liveChatBans.insert", err)
+	if err != nil {
+		return wrapAPIError("liveChatBans.insert", err)
+	}
+	yt.recordBan(func(l *BanLedger) error {
+		return l.recordIssued(liveChatID, channelID, yt.moderatorChannelID(ctx), duration)
+	})
 	return nil
 }
 
-func (yt *YouTubeLive) deleteChatMessage(messageID string) error {
-	return wrapOauthErrors(yt.yclient.service.LiveChatMessages.Delete(messageID).Do())
+// LiveChatID returns the live chat ID of a live broadcast, it is needed to send to the
+// chat without attaching to it.
+func (yt *YouTubeLive) LiveChatID(broadcastID string) (string, error) {
+	return yt.LiveChatIDContext(context.Background(), broadcastID)
+}
+
+// LiveChatIDContext is LiveChatID with a context used for the request.
+func (yt *YouTubeLive) LiveChatIDContext(ctx context.Context, broadcastID string) (string, error) {
+	return yt.getLiveChatID(ctx, broadcastID)
+}
+
+// SendChatMessage sends a message to the live chat without attaching to it, use
+// BotChatMessage when attached.
+func (yt *YouTubeLive) SendChatMessage(liveChatID, message string) error {
+	return yt.SendChatMessageContext(context.Background(), liveChatID, message)
+}
+
+// SendChatMessageContext is SendChatMessage with a context used for the request.
+func (yt *YouTubeLive) SendChatMessageContext(ctx context.Context, liveChatID, message string) error {
+	return yt.sendChatMessage(ctx, liveChatID, message)
+}
+
+// DeleteChatMessage deletes a chat message without attaching to the chat, use
+// BotDeleteMessage when attached.
+func (yt *YouTubeLive) DeleteChatMessage(messageID string) error {
+	return yt.DeleteChatMessageContext(context.Background(), messageID)
+}
+
+// DeleteChatMessageContext is DeleteChatMessage with a context used for the request.
+func (yt *YouTubeLive) DeleteChatMessageContext(ctx context.Context, messageID string) error {
+	return yt.deleteChatMessage(ctx, messageID)
+}
+
+// BanUser bans the channel from the live chat, for the duration or permanently when the
+// duration is 0. Use BotBanUser when attached.
+func (yt *YouTubeLive) BanUser(liveChatID, channelID string, duration time.Duration) error {
+	return yt.BanUserContext(context.Background(), liveChatID, channelID, duration)
+}
+
+// BanUserContext is BanUser with a context used for the request.
+func (yt *YouTubeLive) BanUserContext(ctx context.Context, liveChatID, channelID string, duration time.Duration) error {
+	return yt.banUser(ctx, liveChatID, channelID, duration)
+}
+
+// client returns the current client. The client is replaced when the credentials change so
+// it should be fetched for every request instead of being held on to.
+func (yt *YouTubeLive) client() *ytClient {
+	yt.mu.RLock()
+	defer yt.mu.RUnlock()
+	return yt.yclient
+}
+
+// read runs a read only request. With ReadCredentials the request is spread over the
+// pooled credentials, otherwise the logged-in client is used, or the APIKey when no user
+// is logged in.
+func (yt *YouTubeLive) read(operation string, call func(service *youtube.Service) error) error {
+	observed := func(service *youtube.Service) error {
+		err := call(service)
+		yt.metrics.observeAPI(operation, err)
+		return err
+	}
+	if yt.pool != nil {
+		err := yt.pool.do(operation, observed)
+		if yt.keyPool != nil && errors.Is(err, ErrCredentialsExhausted) {
+			return yt.keyPool.do(operation, observed)
+		}
+		return err
+	}
+	service, err := yt.service()
+	if yt.keyPool != nil && errors.Is(err, NotLoggedIn) {
+		return yt.keyPool.do(operation, observed)
+	}
+	if err != nil {
+		return err
+	}
+	return wrapAPIError(operation, observed(service))
+}
+
+// readPublic runs a read only request for public data, like a channel or video lookup,
+// with the APIKey when it is given and has quota left, otherwise like read.
+func (yt *YouTubeLive) readPublic(operation string, call func(service *youtube.Service) error) error {
+	if yt.keyPool == nil || yt.pool != nil {
+		return yt.read(operation, call)
+	}
+	err := yt.keyPool.do(operation, func(service *youtube.Service) error {
+		err := call(service)
+		yt.metrics.observeAPI(operation, err)
+		return err
+	})
+	if errors.Is(err, ErrCredentialsExhausted) {
+		return yt.read(operation, call)
+	}
+	return err
+}
+
+// service returns the YouTube service of the current client, refreshing it if required.
+func (yt *YouTubeLive) service() (*youtube.Service, error) {
+	service, err := yt.client().youtubeService()
+	return service, wrapAPIError("", err)
 }
 
-func (yt *YouTubeLive) newYtClient() {
-	lResolver := listenResolve{listenAddr: yt.listenAddr}
+// newYtClient creates a client using the given refresh token, yt.mu must be held by the
+// caller.
+func (yt *YouTubeLive) newYtClient(refreshToken string) *ytClient {
+	lResolver := &listenResolve{listenAddr: yt.listenAddr}
 	if yt.yclient != nil {
 		// So we don't open the port an extra time
 		lResolver = yt.yclient.listenR
 	}
-	yt.yclient = &ytClient{
+	return &ytClient{
 		ctx:          yt.ctx,
 		transport:    yt.transport,
 		jar:          yt.jar,
@@ -561,7 +973,7 @@ func (yt *YouTubeLive) newYtClient() {
 		// ordering matters due to a workaround for a rare use case, perhaps add an
 		// OverrideScopes() option in the future for this use case instead.
 		scopes:            append(append(yt.additionalScopes[:0:0], yt.additionalScopes...), requiredScopes...),
-		refreshToken:      yt.refreshToken,
+		refreshToken:      refreshToken,
 		onNewRefreshToken: yt.onNewRefreshToken,
 		autoAuth:          yt.autoAuth,
 		service:           nil,
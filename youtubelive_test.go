@@ -0,0 +1,313 @@
+package youtubelive
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	testBroadcastID = "broadcast"
+	testLiveChatID  = "livechat"
+	// testBotChannelID is the logged-in channel of the fake.
+	testBotChannelID = "channel-bot"
+)
+
+func newTestYouTubeLive(t *testing.T, fake *fakeYouTube, options ...Option) *YouTubeLive {
+	t.Helper()
+	options = append([]Option{RefreshToken("first"), HttpTransport(fake)}, options...)
+	yt, err := NewYouTubeLive("client", "secret", options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return yt
+}
+
+// nextChatMessage waits for the next ChatMessageEvent skipping any other events.
+func nextChatMessage(t *testing.T, events <-chan LiveEvent) *ChatMessageEvent {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("events closed")
+			}
+			if e, ok := event.(*ChatMessageEvent); ok {
+				return e
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for chat message")
+		}
+	}
+}
+
+func TestYouTubeLive_SetRefreshTokenWhileAttached(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, commands, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fake.queue(textMessage("1", "viewer", "hello"))
+	assert.Equal(t, "hello", nextChatMessage(t, events).Message)
+	assert.Equal(t, "access-first", fake.lastAuthorization("/youtube/v3/liveChat/messages"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			yt.SetRefreshToken(fmt.Sprintf("token-%d", i))
+			commands <- BotChatMessage{Message: fmt.Sprintf("message-%d", i)}
+		}()
+	}
+	wg.Wait()
+
+	yt.SetRefreshToken("second")
+	assert.Eventually(t, func() bool {
+		return fake.lastAuthorization("/youtube/v3/liveChat/messages") == "access-second"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	fake.queue(textMessage("2", "viewer", "still here"))
+	assert.Equal(t, "still here", nextChatMessage(t, events).Message)
+	assert.Eventually(t, func() bool {
+		return len(fake.sentMessages()) == 10
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestYouTubeLive_APIKeyOnly(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addChannel("@someone", "UCsomeone")
+	yt, err := NewYouTubeLive("", "", APIKey("key"), HttpTransport(fake))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	channelID, err := yt.ChannelIDFromChannelHandle("@someone")
+	assert.NoError(t, err)
+	assert.Equal(t, "UCsomeone", channelID)
+
+	_, _, err = yt.LoggedInChannel()
+	assert.ErrorIs(t, err, NotLoggedIn)
+	assert.ErrorIs(t, yt.sendChatMessage(context.Background(), testLiveChatID, "hello"), NotLoggedIn)
+	assert.Empty(t, fake.authorizationsFor("/token"))
+}
+
+func TestYouTubeLive_APIKeyWithRefreshToken(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addChannel("@someone", "UCsomeone")
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake, APIKey("key"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := yt.ChannelIDFromChannelHandle("@someone")
+	assert.NoError(t, err)
+	fake.queue(textMessage("1", "viewer", "hello"))
+	events, _, err := yt.Attach(ctx, testBroadcastID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	nextChatMessage(t, events)
+
+	// Public lookups use the key, the chat is read as the logged-in user.
+	assert.Equal(t, []string{"key"}, fake.authorizationsFor("/youtube/v3/channels"))
+	assert.Equal(t, []string{"key"}, fake.authorizationsFor("/youtube/v3/videos"))
+	assert.NotContains(t, fake.authorizationsFor("/youtube/v3/liveChat/messages"), "key")
+	assert.Contains(t, fake.authorizationsFor("/youtube/v3/liveChat/messages"), "access-first")
+}
+
+func TestYouTubeLive_ContextCanceled(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addChannel("@someone", "UCsomeone")
+	yt := newTestYouTubeLive(t, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := yt.ChannelIDFromChannelHandleContext(ctx, "someone")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = yt.IsLiveContext(ctx, "UCsomeone")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = yt.LoggedInChannelContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestYtClient_RefreshDoesNotBlock(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.tokenGate = make(chan struct{})
+	client := newTestYouTubeLive(t, fake).client()
+
+	refreshed := make(chan error, 1)
+	go func() {
+		_, err := client.Token()
+		refreshed <- err
+	}()
+	assert.Eventually(t, func() bool {
+		return fake.callCount("/token") == 1
+	}, 5*time.Second, time.Millisecond)
+
+	// The service is available while the token refresh is in flight.
+	service, err := client.youtubeService()
+	assert.NoError(t, err)
+	assert.NotNil(t, service)
+	close(fake.tokenGate)
+	assert.NoError(t, <-refreshed)
+}
+
+func TestYouTubeLive_ForceLoginKeepsNewerToken(t *testing.T) {
+	yt := newTestYouTubeLive(t, newFakeYouTube())
+	previous := yt.client()
+	yt.SetRefreshToken("second")
+
+	// A login that started before SetRefreshToken does not replace its client.
+	assert.ErrorIs(t, yt.replaceClient(previous, yt.newYtClient(""), "login"), ErrCredentialsChanged)
+	assert.Equal(t, "second", yt.client().refreshToken)
+
+	current := yt.client()
+	login := yt.newYtClient("login")
+	assert.NoError(t, yt.replaceClient(current, login, "login"))
+	assert.Same(t, login, yt.client())
+}
+
+func TestYouTubeLive_ParseChatMessage(t *testing.T) {
+	yt := newTestYouTubeLive(t, newFakeYouTube())
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	message := func(snippet *youtube.LiveChatMessageSnippet) *youtube.LiveChatMessage {
+		snippet.PublishedAt = published.Format(time.RFC3339)
+		return &youtube.LiveChatMessage{
+			Id:            "message",
+			Snippet:       snippet,
+			AuthorDetails: &youtube.LiveChatMessageAuthorDetails{ChannelId: "UCauthor", DisplayName: "author"},
+		}
+	}
+	author := AuthorDetails{ChannelId: "UCauthor", DisplayName: "author"}
+
+	tests := []struct {
+		name    string
+		snippet *youtube.LiveChatMessageSnippet
+		want    LiveEvent
+	}{
+		{
+			name: "new member",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "newSponsorEvent",
+				NewSponsorDetails: &youtube.LiveChatNewSponsorDetails{MemberLevelName: "gold", IsUpgrade: true}},
+			want: &NewMemberEvent{DisplayName: "author", AuthorDetails: author, Level: "gold", IsUpgrade: true,
+				Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name: "deleted",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "messageDeletedEvent", AuthorChannelId: "UCauthor",
+				MessageDeletedDetails: &youtube.LiveChatMessageDeletedDetails{DeletedMessageId: "deleted"}},
+			want: &MessageDeletedEvent{DeletedMessageID: "deleted", ModeratorID: "UCauthor", ModeratorDisplayName: "author",
+				Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name: "retracted",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "messageRetractedEvent",
+				MessageRetractedDetails: &youtube.LiveChatMessageRetractedDetails{RetractedMessageId: "retracted"}},
+			want: &MessageRetractedEvent{RetractedMessageID: "retracted", DisplayName: "author", AuthorDetails: author,
+				Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "members only started",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "sponsorOnlyModeStartedEvent"},
+			want:    &MembersOnlyModeEvent{Enabled: true, Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "members only ended",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "sponsorOnlyModeEndedEvent"},
+			want:    &MembersOnlyModeEvent{Enabled: false, Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "tombstone",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "tombstone"},
+			want:    &TombstoneEvent{MessageID: "message", Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name: "poll",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "pollEvent", PollDetails: &youtube.LiveChatPollDetails{
+				Status: "active",
+				Metadata: &youtube.LiveChatPollDetailsPollMetadata{
+					QuestionText: "best?",
+					Options: []*youtube.LiveChatPollDetailsPollMetadataPollOption{
+						{OptionText: "yes", Tally: 3}, {OptionText: "no", Tally: 1},
+					},
+				},
+			}},
+			want: &PollEvent{PollID: "message", Question: "best?", Status: "active",
+				Options:     []PollOption{{Text: "yes", Tally: 3}, {Text: "no", Tally: 1}},
+				DisplayName: "author", AuthorDetails: author, Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "unknown",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "fanFundingEvent", DisplayMessage: "thanks"},
+			want: &UnknownEvent{MessageID: "message", Type: "fanFundingEvent", DisplayMessage: "thanks",
+				AuthorDetails: author, Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "banned without details",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "userBannedEvent", UserBannedDetails: &youtube.LiveChatUserBannedMessageDetails{}},
+			want: &UnknownEvent{MessageID: "message", Type: "userBannedEvent",
+				AuthorDetails: author, Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "deleted without details",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "messageDeletedEvent", DisplayMessage: "deleted"},
+			want: &UnknownEvent{MessageID: "message", Type: "messageDeletedEvent", DisplayMessage: "deleted",
+				AuthorDetails: author, Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "poll without details",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "pollEvent"},
+			want: &UnknownEvent{MessageID: "message", Type: "pollEvent",
+				AuthorDetails: author, Timestamp: published, NextPageToken: "next"},
+		},
+		{
+			name:    "super sticker without details",
+			snippet: &youtube.LiveChatMessageSnippet{Type: "superStickerEvent"},
+			want: &UnknownEvent{MessageID: "message", Type: "superStickerEvent",
+				AuthorDetails: author, Timestamp: published, NextPageToken: "next"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := parseChatMessage(yt.log, message(tt.snippet), "next")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, event)
+		})
+	}
+}
+
+func TestYouTubeLive_ModerateWithoutAttach(t *testing.T) {
+	fake := newFakeYouTube()
+	fake.addLiveVideo(testBroadcastID, testLiveChatID)
+	yt := newTestYouTubeLive(t, fake)
+
+	liveChatID, err := yt.LiveChatID(testBroadcastID)
+	assert.NoError(t, err)
+	assert.Equal(t, testLiveChatID, liveChatID)
+	assert.NoError(t, yt.SendChatMessage(liveChatID, "hello"))
+	assert.Equal(t, []string{"hello"}, fake.sentMessages())
+
+	assert.NoError(t, yt.BanUser(liveChatID, "UCspam", 0))
+	assert.NoError(t, yt.BanUser(liveChatID, "UCrude", 5*time.Minute))
+	bans := fake.bansIssued()
+	if assert.Len(t, bans, 2) {
+		assert.Equal(t, "permanent", bans[0].Type)
+		assert.Equal(t, "UCspam", bans[0].BannedUserDetails.ChannelId)
+		assert.Equal(t, "temporary", bans[1].Type)
+		assert.Equal(t, uint64(300), bans[1].BanDurationSeconds)
+		assert.Equal(t, testLiveChatID, bans[1].LiveChatId)
+	}
+}
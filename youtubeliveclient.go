@@ -13,11 +13,16 @@ import (
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
 	"log/slog"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 )
 
+// defaultLoginTimeout is how long the Oauth2 workflow waits for the user when the context
+// has no deadline.
+const defaultLoginTimeout = 5 * time.Minute
+
 var (
 	requiredScopes = []string{
 		"https://www.googleapis.com/auth/youtube",
@@ -25,6 +30,11 @@ var (
 )
 
 type ytClient struct {
+	// mu guards the lazily created service and token source as well as the refresh token,
+	// a ytClient is shared by every request and attached poll loop of a YouTubeLive. It is
+	// never held during token refreshes or the login workflow.
+	mu sync.Mutex
+
 	ctx       context.Context
 	transport http.RoundTripper
 	log       *slog.Logger
@@ -38,7 +48,7 @@ type ytClient struct {
 	onNewRefreshToken func(string)
 	autoAuth          bool
 
-	listenR     listenResolve
+	listenR     *listenResolve
 	service     *youtube.Service
 	tokenSource oauth2.TokenSource
 	jar         http.CookieJar
@@ -57,42 +67,93 @@ func newYTClient(ctx context.Context, log *slog.Logger, transport http.RoundTrip
 		clientSecret: clientSecret,
 		scopes:       allScopes,
 		refreshToken: refreshToken,
-		listenR: listenResolve{
+		listenR: &listenResolve{
 			listenAddr: listenAddr,
 		},
 	}
 
 	return c
 }
+
+// refresh sets up the client if needed and refreshes the access token.
 func (yt *ytClient) refresh() error {
-	if yt.service != nil {
+	_, err := yt.youtubeService()
+	return err
+}
 
-		if yt.onNewRefreshToken == nil {
-			return nil
-		}
-		token, err := yt.tokenSource.Token()
+// youtubeService refreshes the client if needed and returns the service to issue requests
+// with.
+func (yt *ytClient) youtubeService() (*youtube.Service, error) {
+	yt.mu.Lock()
+	if !yt.canLogInLocked() {
+		yt.mu.Unlock()
+		// Nothing can succeed without a token, fail before setting up the client so API key
+		// only usage never needs the OAuth2 listener.
+		return nil, NotLoggedIn
+	}
+	err := yt.setupLocked()
+	service, tokenSource, onNewRefreshToken := yt.service, yt.tokenSource, yt.onNewRefreshToken
+	yt.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if onNewRefreshToken == nil {
+		return service, nil
+	}
+
+	// The token refresh, and with autoAuth the login workflow, happen without holding the
+	// lock so they do not hold up the other requests.
+	token, err := tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	yt.mu.Lock()
+	changed := yt.tokenSource == tokenSource && token.RefreshToken != "" && yt.refreshToken != token.RefreshToken
+	if changed {
+		yt.refreshToken = token.RefreshToken
+	}
+	yt.mu.Unlock()
+	if changed {
+		onNewRefreshToken(token.RefreshToken)
+	}
+	return service, nil
+}
+
+// canLogIn reports whether the client has a user, or can obtain one through the login
+// workflow.
+func (yt *ytClient) canLogIn() bool {
+	yt.mu.Lock()
+	defer yt.mu.Unlock()
+	return yt.canLogInLocked()
+}
+
+// canLogInLocked is canLogIn with yt.mu held.
+func (yt *ytClient) canLogInLocked() bool {
+	return yt.service != nil || yt.refreshToken != "" || yt.autoAuth
+}
+
+// setupLocked creates the service and token source when they do not exist yet, it makes
+// no requests. yt.mu must be held.
+func (yt *ytClient) setupLocked() error {
+	if yt.service != nil {
+		return nil
+	}
+	// Clients without a listener only refresh their token, see newCredentialPool.
+	if yt.listenR != nil {
+		err := yt.listenR.setupListener()
+		yt.redirectURI = "http://" + yt.listenR.addr() + "/callback"
 		if err != nil {
 			return err
 		}
-		if yt.refreshToken != token.RefreshToken {
-			yt.refreshToken = token.RefreshToken
-			yt.onNewRefreshToken(token.RefreshToken)
-		}
-		return nil
-	}
-	err := yt.listenR.setupListener()
-	yt.redirectURI = "http://" + yt.listenR.effectiveAddr + "/callback"
-	if err != nil {
-		return err
 	}
-	err = yt.validate()
+	err := yt.validate()
 	if err != nil {
 		return err
 	}
 
 	yt.ctx = context.WithValue(yt.ctx, oauth2.HTTPClient, &http.Client{Transport: yt.transport, Jar: yt.jar})
 
-	yt.tokenSource = yt.createTokenSource(yt.refreshToken, false)
+	yt.tokenSource = yt.createTokenSource(yt.ctx, yt.refreshToken, false)
 	c := oauth2.NewClient(yt.ctx, yt.tokenSource)
 	yt.service, err = youtube.NewService(yt.ctx, option.WithHTTPClient(c))
 	if err != nil {
@@ -102,12 +163,48 @@ func (yt *ytClient) refresh() error {
 	return nil
 }
 
-func (yt *ytClient) createAuthPKCEAuth(endpoint listenResolve) (authhandler.AuthorizationHandler, string, string) {
+// login forces the interactive OAuth2 workflow and replaces the service with one using the
+// newly obtained token. The workflow waits for the user until ctx is done, without holding
+// up the requests of the client. The new refresh token is returned.
+func (yt *ytClient) login(ctx context.Context) (string, error) {
+	yt.mu.Lock()
+	err := yt.setupLocked()
+	yt.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	// The fields used by createTokenSource do not change after the setup.
+	tokenSource := yt.createTokenSource(ctx, "", true)
+	token, err := tokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("login failed: %w", err)
+	}
+
+	c := oauth2.NewClient(yt.ctx, tokenSource)
+	service, err := youtube.NewService(yt.ctx, option.WithHTTPClient(c))
+	if err != nil {
+		return "", err
+	}
+	yt.mu.Lock()
+	defer yt.mu.Unlock()
+	yt.tokenSource = tokenSource
+	yt.service = service
+	yt.refreshToken = token.RefreshToken
+	return token.RefreshToken, nil
+}
+
+// createAuthPKCEAuth returns a handler that waits for the Oauth2 callback until loginCtx
+// is done, or defaultLoginTimeout when loginCtx has no deadline.
+func (yt *ytClient) createAuthPKCEAuth(loginCtx context.Context, endpoint *listenResolve) (authhandler.AuthorizationHandler, string, string) {
 	verifier, challenge := generatePKCE()
 
 	return func(authCodeURL string) (code string, state string, err error) {
 		var authErr error
-		ctx, cancel := context.WithTimeout(yt.ctx, time.Minute*5)
+		timeout := defaultLoginTimeout
+		if deadline, ok := loginCtx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		ctx, cancel := context.WithTimeout(loginCtx, timeout)
 		defer cancel()
 
 		mux := http.NewServeMux()
@@ -143,13 +240,13 @@ func (yt *ytClient) createAuthPKCEAuth(endpoint listenResolve) (authhandler.Auth
 
 		go func() {
 			defer wg.Done()
-			yt.log.Info("starting local server", "listen", fmt.Sprintf("http://%s", endpoint))
+			yt.log.Info("starting local server", "listen", fmt.Sprintf("http://%s", endpoint.addr()))
 			if err != nil {
 				authErr = err
 				return
 			}
 
-			if authErr = server.Serve(endpoint.stickyPort); authErr != nil {
+			if authErr = server.Serve(endpoint.listener()); authErr != nil {
 				if errors.Is(authErr, http.ErrServerClosed) {
 					authErr = nil
 					return
@@ -191,7 +288,7 @@ func (yt *ytClient) validate() error {
 	if yt.clientID == "" {
 		errs = errors.Join(fmt.Errorf("YouTube Client ID is empty"))
 	}
-	if yt.redirectURI == "" {
+	if yt.redirectURI == "" && yt.listenR != nil {
 		errs = errors.Join(fmt.Errorf("YouTube Redirect URI is empty"))
 	}
 	if len(yt.scopes) == 0 {
@@ -201,15 +298,19 @@ func (yt *ytClient) validate() error {
 }
 
 func (yt *ytClient) Token() (*oauth2.Token, error) {
-	err := yt.refresh()
+	yt.mu.Lock()
+	err := yt.setupLocked()
+	tokenSource := yt.tokenSource
+	yt.mu.Unlock()
 	if err != nil {
 		return nil, err
 	}
-	return yt.tokenSource.Token()
-
+	return tokenSource.Token()
 }
 
-func (yt *ytClient) createTokenSource(refreshToken string, useDefault bool) oauth2.TokenSource {
+// createTokenSource returns the token source for the refresh token. loginCtx bounds the
+// interactive workflow when it is used.
+func (yt *ytClient) createTokenSource(loginCtx context.Context, refreshToken string, useDefault bool) oauth2.TokenSource {
 	conf := &oauth2.Config{
 		ClientID:     yt.clientID,
 		ClientSecret: yt.clientSecret,
@@ -224,8 +325,8 @@ func (yt *ytClient) createTokenSource(refreshToken string, useDefault bool) oaut
 	}
 
 	if yt.autoAuth || useDefault {
-		handler, challenge, verifier := yt.createAuthPKCEAuth(yt.listenR)
-		return RefreshTokenSourceWithPKCE(yt.ctx,
+		handler, challenge, verifier := yt.createAuthPKCEAuth(loginCtx, yt.listenR)
+		return tokenErrorSource{RefreshTokenSourceWithPKCE(yt.ctx,
 			conf,
 			token,
 			"",
@@ -242,7 +343,23 @@ func (yt *ytClient) createTokenSource(refreshToken string, useDefault bool) oaut
 			oauth2.SetAuthURLParam("force_verify", "true"),
 			oauth2.AccessTypeOffline,
 			oauth2.ApprovalForce,
-		)
+		)}
+	}
+	return tokenErrorSource{conf.TokenSource(yt.ctx, token)}
+}
+
+// tokenErrorSource marks the errors of a token source with errTokenSource, the oauth2
+// package returns untyped errors such as for an expired token without a refresh token.
+// Network errors are left as they are so a short outage is not taken for a logout.
+type tokenErrorSource struct {
+	oauth2.TokenSource
+}
+
+func (s tokenErrorSource) Token() (*oauth2.Token, error) {
+	token, err := s.TokenSource.Token()
+	netErr := net.Error(nil)
+	if err != nil && !errors.As(err, &netErr) {
+		err = fmt.Errorf("%w: %w", errTokenSource, err)
 	}
-	return conf.TokenSource(yt.ctx, token)
+	return token, err
 }